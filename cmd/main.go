@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal"
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+	"github.com/joho/godotenv"
+)
+
+const usage = `usage: main <command> [flags]
+
+commands:
+  crawl     crawl blogs from the given seeds
+  daemon    keep the database up to date until interrupted
+  schedule  print the daemon schedule
+  train     train the tag classifier and report its precision and recall
+  classify  tag untagged referenced problems with the trained classifier
+  migrate   apply pending database migrations
+`
+
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+func crawlCommand(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("crawl", flag.ExitOnError)
+	blogs := flags.String("blogs", "", "comma-separated blog IDs to crawl")
+	recent := flags.Int("recent", 0, "seed with blogs from the last N recent actions")
+	authors := flags.String("authors", "", "comma-separated handles whose blogs to seed")
+	from := flags.Int("from", 0, "first blog ID of a range to seed")
+	to := flags.Int("to", 0, "last blog ID of a range to seed")
+	tags := flags.String("tags", "", "comma-separated tags of crawled blogs to seed")
+	workers := flags.Int("workers", internal.DefaultWorkers, "number of concurrent workers")
+	depth := flags.Int("depth", internal.DefaultMaxDepth, "maximum link depth, 0 for unlimited")
+	flags.Parse(args)
+
+	crawler := internal.NewCrawler()
+	crawler.Workers = *workers
+	crawler.MaxDepth = *depth
+
+	for _, blog := range splitList(*blogs) {
+		var blogID int
+		if _, err := fmt.Sscan(blog, &blogID); err != nil {
+			return fmt.Errorf("invalid blog ID %q", blog)
+		}
+		if err := crawler.Enqueue(blogID, 0); err != nil {
+			return err
+		}
+	}
+
+	config := internal.SeedConfig{
+		RecentActions: *recent,
+		Authors:       splitList(*authors),
+		RangeFrom:     *from,
+		RangeTo:       *to,
+		Tags:          splitList(*tags),
+	}
+	log.Printf("Seeded %d blogs...\n", crawler.Seed(ctx, config.Seeders()...))
+
+	if err := crawler.Run(ctx); err != nil {
+		return err
+	}
+
+	// Run returns once nothing is left to claim, so whatever is still pending
+	// is waiting for a retry.
+	pending, err := internal.CountFrontier(internal.FrontierPending)
+	if err != nil || pending == 0 {
+		return err
+	}
+	retryAt, err := internal.NextFrontierRetry()
+	if err != nil {
+		return err
+	}
+	log.Printf("%d blogs are still pending a retry, run crawl again after %s to resume them...\n", pending, retryAt.Format(time.RFC3339))
+	return nil
+}
+
+func daemonCommand(ctx context.Context, args []string) error {
+	daemon := internal.NewDaemon()
+
+	flags := flag.NewFlagSet("daemon", flag.ExitOnError)
+	flags.DurationVar(&daemon.RecentActionsInterval, "recent-interval", daemon.RecentActionsInterval, "how often to poll recent actions")
+	flags.DurationVar(&daemon.ProblemsInterval, "problems-interval", daemon.ProblemsInterval, "how often to refresh the problemset")
+	flags.DurationVar(&daemon.RecrawlInterval, "recrawl-interval", daemon.RecrawlInterval, "how often to look for blogs due for re-crawling")
+	flags.IntVar(&daemon.RecrawlMinRating, "recrawl-rating", daemon.RecrawlMinRating, "minimum rating of re-crawled blogs")
+	flags.IntVar(&daemon.Crawler.Workers, "workers", daemon.Crawler.Workers, "number of concurrent workers")
+	flags.Parse(args)
+
+	return daemon.Run(ctx)
+}
+
+func scheduleCommand(ctx context.Context, args []string) error {
+	schedule, err := internal.NewDaemon().Schedule()
+	if err != nil {
+		return err
+	}
+
+	for _, job := range schedule {
+		lastRun := "never"
+		if !job.LastRun.IsZero() {
+			lastRun = job.LastRun.Format(time.RFC3339)
+		}
+		fmt.Printf("%-16s every %-10s last run %-25s next run %s\n", job.Name, job.Interval, lastRun, job.NextRun.Format(time.RFC3339))
+		if job.LastError != "" {
+			fmt.Printf("%-16s last error: %s\n", "", job.LastError)
+		}
+	}
+
+	return nil
+}
+
+func trainCommand(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("train", flag.ExitOnError)
+	model := flags.String("model", "tag_model.json", "path to save the model to")
+	holdout := flags.Float64("holdout", 0.2, "fraction of problems held out for evaluation")
+	threshold := flags.Float64("threshold", internal.DefaultClassifierThreshold, "minimum probability of a predicted tag")
+	flags.Parse(args)
+
+	examples, err := internal.GetTagExamples()
+	if err != nil {
+		return err
+	}
+	if len(examples) == 0 {
+		return fmt.Errorf("no referenced problems with known tags to train on")
+	}
+
+	train, test := internal.SplitTagExamples(examples, *holdout)
+	evaluated := internal.NewTagClassifier()
+	evaluated.Threshold = *threshold
+	evaluated.Train(train)
+	fmt.Print(evaluated.Evaluate(test))
+
+	classifier := internal.NewTagClassifier()
+	classifier.Threshold = *threshold
+	classifier.Train(examples)
+	return classifier.Save(*model)
+}
+
+func classifyCommand(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("classify", flag.ExitOnError)
+	model := flags.String("model", "tag_model.json", "path of the trained model")
+	flags.Parse(args)
+
+	classifier, err := internal.LoadTagClassifier(*model)
+	if err != nil {
+		return err
+	}
+
+	tagged, err := internal.ClassifyUntaggedProblems(classifier)
+	log.Printf("Tagged %d referenced problems...\n", tagged)
+	return err
+}
+
+func migrateCommand(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "print the pending migrations without applying them")
+	flags.Parse(args)
+
+	if err := internal.ConnectDB(internal.DatabaseURL()); err != nil {
+		return err
+	}
+
+	version, err := internal.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Schema version: %d\n", version)
+
+	migrations, err := internal.Migrate(*dryRun)
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		fmt.Println("The database is up to date.")
+		return nil
+	}
+
+	for _, migration := range migrations {
+		if *dryRun {
+			fmt.Printf("-- Pending %s\n%s\n", migration, migration.SQL)
+		} else {
+			fmt.Printf("Applied %s\n", migration)
+		}
+	}
+
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	commands := map[string]func(context.Context, []string) error{
+		"crawl":    crawlCommand,
+		"daemon":   daemonCommand,
+		"schedule": scheduleCommand,
+		"train":    trainCommand,
+		"classify": classifyCommand,
+		"migrate":  migrateCommand,
+	}
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// DefaultClient is configured before .env is loaded, so pick up its keys.
+	if godotenv.Load() == nil {
+		codeforces.DefaultClient = codeforces.NewClientFromEnv()
+	}
+
+	// migrate opens the database itself, to leave migrating it up to its flags.
+	if os.Args[1] != "migrate" {
+		internal.InitDB()
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := command(ctx, os.Args[2:])
+	stop()
+	internal.CloseDB()
+
+	if err != nil && err != context.Canceled {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
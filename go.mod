@@ -6,6 +6,7 @@ require (
 	github.com/PuerkitoBio/goquery v1.9.0
 	github.com/gin-gonic/gin v1.9.1
 	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.10.9
 	github.com/mattn/go-sqlite3 v1.14.22
 )
 
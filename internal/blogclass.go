@@ -0,0 +1,247 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+)
+
+type BlogClass string
+
+const (
+	BlogEditorial    BlogClass = "editorial"
+	BlogAnnouncement BlogClass = "announcement"
+	BlogQuestion     BlogClass = "question"
+	BlogTutorial     BlogClass = "tutorial"
+	BlogDiscussion   BlogClass = "discussion"
+)
+
+// CrawlPolicy decides which parts of a blog of some class are analyzed and
+// whether the blogs it links to are crawled.
+type CrawlPolicy struct {
+	AnalyzeContent  bool
+	AnalyzeComments bool
+	FollowLinks     bool
+}
+
+// DefaultCrawlPolicies analyze every class except the content of
+// announcements, which lists the authors' older problems rather than
+// discussing them.
+var DefaultCrawlPolicies = map[BlogClass]CrawlPolicy{
+	BlogEditorial:    {AnalyzeContent: true, AnalyzeComments: true, FollowLinks: true},
+	BlogAnnouncement: {AnalyzeContent: false, AnalyzeComments: true, FollowLinks: true},
+	BlogQuestion:     {AnalyzeContent: true, AnalyzeComments: true, FollowLinks: true},
+	BlogTutorial:     {AnalyzeContent: true, AnalyzeComments: true, FollowLinks: true},
+	BlogDiscussion:   {AnalyzeContent: true, AnalyzeComments: true, FollowLinks: true},
+}
+
+type blogClassKeyword struct {
+	class  BlogClass
+	regex  *regexp.Regexp
+	weight float64
+}
+
+func blogKeywords(class BlogClass, weight float64, pattern string) blogClassKeyword {
+	return blogClassKeyword{class: class, regex: regexp.MustCompile(`(?i)` + pattern), weight: weight}
+}
+
+// Title keywords by locale. English keywords apply to every blog, since
+// many blogs in other locales still use them.
+var blogTitleKeywords = map[string][]blogClassKeyword{
+	"en": {
+		blogKeywords(BlogEditorial, 3, `\beditorials?\b`),
+		blogKeywords(BlogEditorial, 2, `\b(solutions?|analysis)\b`),
+		blogKeywords(BlogEditorial, 1, `\btutorial\b`),
+		// A tutorial named after a round is the round's editorial.
+		blogKeywords(BlogEditorial, 2, `\b(round|contest|div\.? ?\d)\b.*\btutorial\b`),
+		blogKeywords(BlogAnnouncement, 3, `\b(announcement|invitation|invites? you)\b`),
+		blogKeywords(BlogAnnouncement, 1, `\b(round|contest|cup|olympiad|div\.? ?\d)\b`),
+		blogKeywords(BlogQuestion, 3, `\?\s*$`),
+		blogKeywords(BlogQuestion, 2, `\b(help|question|how (to|do|can)|why|wrong answer|wa on|tle|getting (wa|tle|re|mle))\b`),
+		blogKeywords(BlogTutorial, 2, `\b(tutorial|guide|introduction to|tricks?|techniques?|explained|lecture)\b`),
+		blogKeywords(BlogTutorial, 1, `\b(how to use|an? (simple|short) (way|approach))\b`),
+	},
+	"ru": {
+		blogKeywords(BlogEditorial, 3, `разбор`),
+		blogKeywords(BlogEditorial, 2, `решени[яей]`),
+		blogKeywords(BlogAnnouncement, 3, `анонс|приглаш`),
+		blogKeywords(BlogAnnouncement, 1, `раунд|соревновани|олимпиад|контест`),
+		blogKeywords(BlogQuestion, 2, `помогите|вопрос|почему|как (сделать|решить)`),
+		blogKeywords(BlogTutorial, 2, `туториал|руководство|введение в|обучени|лекци`),
+	},
+	"zh": {
+		blogKeywords(BlogEditorial, 3, `题解`),
+		blogKeywords(BlogAnnouncement, 3, `公告|邀请`),
+		blogKeywords(BlogQuestion, 2, `求助|问题|为什么`),
+		blogKeywords(BlogTutorial, 2, `教程|入门`),
+	},
+}
+
+// Blog tags carry the same hints, in any locale.
+var blogTagClasses = map[string]BlogClass{
+	"editorial":    BlogEditorial,
+	"разбор":       BlogEditorial,
+	"题解":           BlogEditorial,
+	"announcement": BlogAnnouncement,
+	"анонс":        BlogAnnouncement,
+	"invitation":   BlogAnnouncement,
+	"help":         BlogQuestion,
+	"question":     BlogQuestion,
+	"помощь":       BlogQuestion,
+	"вопрос":       BlogQuestion,
+	"tutorial":     BlogTutorial,
+	"guide":        BlogTutorial,
+	"туториал":     BlogTutorial,
+	"обучение":     BlogTutorial,
+}
+
+var announcementContentRegex = regexp.MustCompile(`(?i)(score distribution|scoring distribution|good luck|will take place|will start|is rated for|разбалловка|удачи)`)
+var headingRegex = regexp.MustCompile(`(?i)<h[1-6][\s>]`)
+var spoilerRegex = regexp.MustCompile(`(?i)class\s*=\s*["'][^"']*spoiler`)
+var codeBlockRegex = regexp.MustCompile(`(?i)<pre[\s>]`)
+
+// ClassifyBlog scores every class by keywords in the blog's title and tags,
+// picked by its locale, and by the structure of its content: editorials
+// hide solutions to several problems of one contest in spoilers,
+// announcements state the scoring, tutorials are long with headings and
+// code. Blogs without a clear signal are discussions.
+func ClassifyBlog(blog *codeforces.BlogEntry) BlogClass {
+	scores := make(map[BlogClass]float64)
+
+	title := strings.TrimSpace(htmlToText(blog.Title))
+	keywords := blogTitleKeywords["en"]
+	locales := []string{blog.Locale}
+	if blog.OriginalLocale != blog.Locale {
+		locales = append(locales, blog.OriginalLocale)
+	}
+	for _, locale := range locales {
+		if locale != "en" {
+			keywords = append(keywords, blogTitleKeywords[locale]...)
+		}
+	}
+	for _, keyword := range keywords {
+		if keyword.regex.MatchString(title) {
+			scores[keyword.class] += keyword.weight
+		}
+	}
+
+	for _, tag := range blog.Tags {
+		if class, ok := blogTagClasses[strings.ToLower(tag)]; ok {
+			scores[class] += 2
+		}
+	}
+
+	if _, problems := editorialContest(blog); problems >= 3 {
+		scores[BlogEditorial] += 2
+		if len(spoilerRegex.FindAllStringIndex(blog.Content, -1)) >= 2 {
+			scores[BlogEditorial] += 2
+		}
+	}
+	if announcementContentRegex.MatchString(blog.Content) {
+		scores[BlogAnnouncement] += 2
+	}
+	if len(headingRegex.FindAllStringIndex(blog.Content, -1)) >= 3 && len(codeBlockRegex.FindAllStringIndex(blog.Content, -1)) >= 1 {
+		scores[BlogTutorial] += 1
+	}
+
+	// An editorial's title usually names the round too, so only a stronger
+	// announcement signal wins.
+	best, bestScore := BlogDiscussion, 1.5
+	for _, class := range []BlogClass{BlogEditorial, BlogAnnouncement, BlogTutorial, BlogQuestion} {
+		if scores[class] > bestScore {
+			best, bestScore = class, scores[class]
+		}
+	}
+
+	return best
+}
+
+// editorialContest returns the contest most of the blog's problem links
+// point to and how many of its problems are linked.
+func editorialContest(blog *codeforces.BlogEntry) (int, int) {
+	problems := make(map[int]map[string]bool)
+	for _, link := range codeforces.FindLinks(blog.Content) {
+		if link.Kind != codeforces.LinkProblem || (link.Type != codeforces.LinkTypeContest && link.Type != codeforces.LinkTypeProblemset) {
+			continue
+		}
+		if problems[link.ContestID] == nil {
+			problems[link.ContestID] = make(map[string]bool)
+		}
+		problems[link.ContestID][link.Index] = true
+	}
+
+	contestID, count := 0, 0
+	for id, indices := range problems {
+		if len(indices) > count || (len(indices) == count && id < contestID) {
+			contestID, count = id, len(indices)
+		}
+	}
+
+	return contestID, count
+}
+
+// AnalyzeEditorial maps an editorial to the problems of its contest that
+// were fetched from the API, saving each problem's section of the editorial
+// as its snippet. Problems are found by link, by name or by a reference
+// like "1923B".
+func AnalyzeEditorial(blog *codeforces.BlogEntry, contestID int) error {
+	if contestID == 0 {
+		return nil
+	}
+
+	problems, err := GetContestProblems(contestID)
+	if err != nil {
+		return err
+	}
+
+	text := htmlToText(blog.Content)
+	type section struct {
+		problem *codeforces.Problem
+		start   int
+	}
+	sections := []section{}
+	for _, problem := range problems {
+		start := -1
+		for _, needle := range []string{
+			strings.ToLower(fmt.Sprintf("/contest/%d/problem/%s", contestID, problem.Index)),
+			strings.ToLower(fmt.Sprintf("/problemset/problem/%d/%s", contestID, problem.Index)),
+			strings.ToLower(fmt.Sprintf("%d%s", contestID, problem.Index)),
+			strings.ToLower(problem.Name),
+		} {
+			if i := strings.Index(text, needle); needle != "" && i >= 0 && (start < 0 || i < start) {
+				start = i
+			}
+		}
+		if start >= 0 {
+			// Start at the beginning of the word, e.g. the link's scheme.
+			start = strings.LastIndex(text[:start], " ") + 1
+			sections = append(sections, section{problem: problem, start: start})
+		}
+	}
+	sort.Slice(sections, func(i, j int) bool { return sections[i].start < sections[j].start })
+
+	for i, section := range sections {
+		end := len(text)
+		if i+1 < len(sections) {
+			end = sections[i+1].start
+		}
+		snippet := strings.TrimSpace(text[section.start:end])
+
+		referenced := &codeforces.ReferencedProblem{
+			BlogID:      blog.ID,
+			ProblemType: codeforces.LinkTypeContest,
+			ProblemID:   contestID,
+			Index:       section.problem.Index,
+			Tags:        FindTagsInSection(snippet),
+			Snippet:     snippet,
+		}
+		if err := SaveReferencedProblem(referenced); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,406 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const DefaultClassifierThreshold = 0.5
+
+// TagExample is the snippets mentioning a problem across all crawled blogs,
+// together with the problem's official tags when they are known.
+type TagExample struct {
+	Problem string
+	Text    string
+	Tags    []string
+}
+
+// TagClassifier is a one-vs-rest naive Bayes classifier over the words
+// around problem mentions. Words are binary features: tokenize drops
+// repeats, so each word counts once per example however often it appears.
+type TagClassifier struct {
+	Threshold     float64                   `json:"threshold"`
+	Examples      int                       `json:"examples"`
+	TagExamples   map[string]int            `json:"tagExamples"`
+	Words         map[string]int            `json:"words"`
+	WordTotal     int                       `json:"wordTotal"`
+	TagWords      map[string]map[string]int `json:"tagWords"`
+	TagWordTotals map[string]int            `json:"tagWordTotals"`
+}
+
+func NewTagClassifier() *TagClassifier {
+	return &TagClassifier{
+		Threshold:     DefaultClassifierThreshold,
+		TagExamples:   make(map[string]int),
+		Words:         make(map[string]int),
+		TagWords:      make(map[string]map[string]int),
+		TagWordTotals: make(map[string]int),
+	}
+}
+
+func LoadTagClassifier(path string) (*TagClassifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	classifier := NewTagClassifier()
+	if err := json.Unmarshal(data, classifier); err != nil {
+		return nil, err
+	}
+
+	return classifier, nil
+}
+
+func (c *TagClassifier) Save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+var classifierStopWords = map[string]bool{
+	"http": true, "https": true, "www": true, "com": true, "codeforces": true,
+	"contest": true, "problemset": true, "problem": true, "gym": true, "blog": true, "entry": true,
+	"the": true, "and": true, "is": true, "it": true, "to": true, "of": true, "in": true, "this": true, "that": true,
+}
+
+// tokenize splits text into distinct lowercase words, dropping numbers,
+// single letters and stop words.
+func tokenize(text string) []string {
+	seen := make(map[string]bool)
+	words := []string{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) < 2 || classifierStopWords[word] || strings.IndexFunc(word, unicode.IsLetter) < 0 || seen[word] {
+			continue
+		}
+		seen[word] = true
+		words = append(words, word)
+	}
+
+	return words
+}
+
+func (c *TagClassifier) Train(examples []TagExample) {
+	for _, example := range examples {
+		words := tokenize(example.Text)
+
+		c.Examples++
+		c.WordTotal += len(words)
+		for _, word := range words {
+			c.Words[word]++
+		}
+
+		for _, tag := range example.Tags {
+			if c.TagWords[tag] == nil {
+				c.TagWords[tag] = make(map[string]int)
+			}
+
+			c.TagExamples[tag]++
+			c.TagWordTotals[tag] += len(words)
+			for _, word := range words {
+				c.TagWords[tag][word]++
+			}
+		}
+	}
+}
+
+// Predict returns the probability of every known tag for text, by
+// decreasing probability. Words never seen in training are ignored.
+func (c *TagClassifier) Predict(text string) []TagScore {
+	words := tokenize(text)
+	vocabulary := float64(len(c.Words))
+
+	scores := []TagScore{}
+	for tag, examples := range c.TagExamples {
+		tagTotal := float64(c.TagWordTotals[tag])
+		otherTotal := float64(c.WordTotal) - tagTotal
+
+		logOdds := math.Log(float64(examples)+1) - math.Log(float64(c.Examples-examples)+1)
+		for _, word := range words {
+			count, ok := c.Words[word]
+			if !ok {
+				continue
+			}
+			tagCount := c.TagWords[tag][word]
+
+			logOdds += math.Log((float64(tagCount)+1)/(tagTotal+vocabulary)) - math.Log((float64(count-tagCount)+1)/(otherTotal+vocabulary))
+		}
+
+		scores = append(scores, TagScore{Tag: tag, Confidence: 1 / (1 + math.Exp(-logOdds))})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Confidence != scores[j].Confidence {
+			return scores[i].Confidence > scores[j].Confidence
+		}
+		return scores[i].Tag < scores[j].Tag
+	})
+
+	return scores
+}
+
+// Classify returns the tags whose probability reaches the threshold.
+func (c *TagClassifier) Classify(text string) []string {
+	tags := []string{}
+	for _, score := range c.Predict(text) {
+		if score.Confidence >= c.Threshold {
+			tags = append(tags, score.Tag)
+		}
+	}
+
+	return tags
+}
+
+type TagMetrics struct {
+	Tag            string
+	Support        int
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+}
+
+func (m TagMetrics) Precision() float64 {
+	if m.TruePositives+m.FalsePositives == 0 {
+		return 0
+	}
+	return float64(m.TruePositives) / float64(m.TruePositives+m.FalsePositives)
+}
+
+func (m TagMetrics) Recall() float64 {
+	if m.TruePositives+m.FalseNegatives == 0 {
+		return 0
+	}
+	return float64(m.TruePositives) / float64(m.TruePositives+m.FalseNegatives)
+}
+
+type TagReport struct {
+	Examples int
+	Tags     []TagMetrics
+	Total    TagMetrics
+}
+
+func (r TagReport) String() string {
+	var report strings.Builder
+	fmt.Fprintf(&report, "%-28s %9s %9s %9s\n", "tag", "precision", "recall", "support")
+	for _, metrics := range r.Tags {
+		fmt.Fprintf(&report, "%-28s %9.3f %9.3f %9d\n", metrics.Tag, metrics.Precision(), metrics.Recall(), metrics.Support)
+	}
+	fmt.Fprintf(&report, "%-28s %9.3f %9.3f %9d\n", r.Total.Tag, r.Total.Precision(), r.Total.Recall(), r.Total.Support)
+	fmt.Fprintf(&report, "%d examples\n", r.Examples)
+
+	return report.String()
+}
+
+// Evaluate classifies examples and compares the result to their tags.
+func (c *TagClassifier) Evaluate(examples []TagExample) TagReport {
+	metrics := make(map[string]*TagMetrics)
+	get := func(tag string) *TagMetrics {
+		if metrics[tag] == nil {
+			metrics[tag] = &TagMetrics{Tag: tag}
+		}
+		return metrics[tag]
+	}
+
+	for _, example := range examples {
+		predicted := make(map[string]bool)
+		for _, tag := range c.Classify(example.Text) {
+			predicted[tag] = true
+		}
+
+		actual := make(map[string]bool)
+		for _, tag := range example.Tags {
+			actual[tag] = true
+			get(tag).Support++
+			if predicted[tag] {
+				get(tag).TruePositives++
+			} else {
+				get(tag).FalseNegatives++
+			}
+		}
+		for tag := range predicted {
+			if !actual[tag] {
+				get(tag).FalsePositives++
+			}
+		}
+	}
+
+	report := TagReport{Examples: len(examples), Total: TagMetrics{Tag: "total"}}
+	for _, m := range metrics {
+		report.Tags = append(report.Tags, *m)
+		report.Total.Support += m.Support
+		report.Total.TruePositives += m.TruePositives
+		report.Total.FalsePositives += m.FalsePositives
+		report.Total.FalseNegatives += m.FalseNegatives
+	}
+	sort.Slice(report.Tags, func(i, j int) bool { return report.Tags[i].Tag < report.Tags[j].Tag })
+
+	return report
+}
+
+// SplitTagExamples deterministically holds out about the given fraction of
+// the problems for evaluation.
+func SplitTagExamples(examples []TagExample, holdout float64) (train []TagExample, test []TagExample) {
+	for _, example := range examples {
+		hash := fnv.New32a()
+		hash.Write([]byte(example.Problem))
+		if float64(hash.Sum32()%1000) < holdout*1000 {
+			test = append(test, example)
+		} else {
+			train = append(train, example)
+		}
+	}
+
+	return train, test
+}
+
+// ReferenceContext is a mention of a problem that isn't stale, with the
+// problem's official tags when they are known.
+type ReferenceContext struct {
+	ID           int
+	Problem      string
+	Text         string
+	Tags         []string
+	OfficialTags []string
+}
+
+// getOfficialTags returns the tags of the problems fetched from the API by
+// "<contest>/<index>".
+func (s *sqlStore) getOfficialTags() (map[string][]string, error) {
+	rows, err := s.db.Query("SELECT contest_id, idx, tag FROM problem_tags ORDER BY contest_id, idx, tag")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var contestID int
+		var index, tag string
+		if err := rows.Scan(&contestID, &index, &tag); err != nil {
+			return nil, err
+		}
+		problem := fmt.Sprintf("%d/%s", contestID, index)
+		tags[problem] = append(tags[problem], tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func (s *sqlStore) GetReferenceContexts() ([]*ReferenceContext, error) {
+	officialTags, err := s.getOfficialTags()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`SELECT id, problem_type, problem_id, idx, tags, COALESCE(snippet, '')
+		FROM referenced_problems
+		WHERE NOT COALESCE(stale, FALSE)
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	references := []*ReferenceContext{}
+	for rows.Next() {
+		var problemType, index string
+		var problemID int
+		var marshaledTags []byte
+		reference := new(ReferenceContext)
+		if err := rows.Scan(&reference.ID, &problemType, &problemID, &index, &marshaledTags, &reference.Text); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(marshaledTags, &reference.Tags); err != nil {
+			return nil, err
+		}
+		if problemType == "contest" || problemType == "problemset" {
+			reference.OfficialTags = officialTags[fmt.Sprintf("%d/%s", problemID, index)]
+		}
+
+		// Problemset and contest links name the same problem.
+		if problemType == "problemset" {
+			problemType = "contest"
+		}
+		reference.Problem = fmt.Sprintf("%s/%d/%s", problemType, problemID, index)
+
+		references = append(references, reference)
+	}
+
+	return references, rows.Err()
+}
+
+// GetTagExamples returns one example per referenced problem whose official
+// tags are known, with the snippets of all of its mentions.
+func GetTagExamples() ([]TagExample, error) {
+	references, err := store.GetReferenceContexts()
+	if err != nil {
+		return nil, err
+	}
+
+	examples := []TagExample{}
+	byProblem := make(map[string]int)
+	for _, reference := range references {
+		if len(reference.OfficialTags) == 0 || reference.Text == "" {
+			continue
+		}
+
+		if i, ok := byProblem[reference.Problem]; ok {
+			examples[i].Text += " " + reference.Text
+			continue
+		}
+		byProblem[reference.Problem] = len(examples)
+		examples = append(examples, TagExample{Problem: reference.Problem, Text: reference.Text, Tags: reference.OfficialTags})
+	}
+
+	return examples, nil
+}
+
+// ClassifyUntaggedProblems tags the referenced problems that have no tags
+// yet and returns how many it tagged.
+func ClassifyUntaggedProblems(classifier *TagClassifier) (int, error) {
+	references, err := store.GetReferenceContexts()
+	if err != nil {
+		return 0, err
+	}
+
+	tagged := 0
+	for _, reference := range references {
+		if len(reference.Tags) != 0 || reference.Text == "" {
+			continue
+		}
+
+		tags := classifier.Classify(reference.Text)
+		if len(tags) == 0 {
+			continue
+		}
+
+		if err := store.SaveReferenceTags(reference.ID, tags); err != nil {
+			return tagged, err
+		}
+		tagged++
+	}
+
+	return tagged, nil
+}
+
+// SaveReferenceTags replaces the tags of the mention with id referenceID.
+func (s *sqlStore) SaveReferenceTags(referenceID int, tags []string) error {
+	marshaledTags, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec("UPDATE referenced_problems SET tags = ? WHERE id = ?", string(marshaledTags), referenceID)
+	return err
+}
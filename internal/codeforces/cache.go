@@ -0,0 +1,191 @@
+package codeforces
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Forever is a TTL for responses that never change, such as the standings
+// of a finished contest.
+const Forever time.Duration = -1
+
+// Cache stores raw API results keyed by their canonical request URL.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, result []byte, ttl time.Duration) error
+}
+
+// CachePolicy returns how long the result of an API method may be cached.
+// Zero disables caching for that response.
+type CachePolicy func(method string, result []byte) time.Duration
+
+func DefaultCachePolicy(method string, result []byte) time.Duration {
+	switch method {
+	case "problemset.problems":
+		return 24 * time.Hour
+	case "contest.list", "user.ratedList", "user.rating", "user.blogEntries":
+		return time.Hour
+	case "user.info":
+		return 10 * time.Minute
+	case "contest.standings":
+		standings := struct {
+			Contest Contest `json:"contest"`
+		}{}
+		if json.Unmarshal(result, &standings) == nil && standings.Contest.Phase == "FINISHED" {
+			return Forever
+		}
+		return 10 * time.Second
+	case "contest.ratingChanges":
+		if len(result) > 2 {
+			return Forever
+		}
+		return time.Minute
+	case "contest.status", "contest.hacks", "user.status":
+		return 30 * time.Second
+	}
+
+	// Blog entries and their comments aren't cached: the crawler compares
+	// their modification times and comment hashes with the database.
+	return 0
+}
+
+type cacheBypassKey struct{}
+
+// WithoutCache returns a context whose requests skip the cache lookup. Fresh
+// results are still written back to the cache.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+type CacheStats struct {
+	Hits    int64
+	Misses  int64
+	Expired int64
+	Stores  int64
+}
+
+// FileCache keeps one JSON file per cached response under Dir.
+type FileCache struct {
+	Dir string
+
+	hits    atomic.Int64
+	misses  atomic.Int64
+	expired atomic.Int64
+	stores  atomic.Int64
+}
+
+func NewFileCache(dir string) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileCache{Dir: dir}, nil
+}
+
+type cacheEntry struct {
+	Key       string          `json:"key"`
+	ExpiresAt int64           `json:"expiresAt"`
+	Result    json.RawMessage `json:"result"`
+}
+
+func (c *FileCache) path(key string) string {
+	return filepath.Join(c.Dir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(key))))
+}
+
+func (c *FileCache) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	entry := cacheEntry{}
+	if err = json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		c.misses.Add(1)
+		return nil, false
+	}
+	if entry.ExpiresAt > 0 && time.Now().Unix() >= entry.ExpiresAt {
+		c.expired.Add(1)
+		c.misses.Add(1)
+		os.Remove(c.path(key))
+		return nil, false
+	}
+
+	c.hits.Add(1)
+	return entry.Result, true
+}
+
+func (c *FileCache) Set(key string, result []byte, ttl time.Duration) error {
+	entry := cacheEntry{Key: key, Result: result}
+	if ttl != Forever {
+		entry.ExpiresAt = time.Now().Add(ttl).Unix()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.Dir, "entry-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return err
+	}
+
+	c.stores.Add(1)
+	return nil
+}
+
+func (c *FileCache) Stats() CacheStats {
+	return CacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Expired: c.expired.Load(),
+		Stores:  c.stores.Load(),
+	}
+}
+
+// Clear removes every cached response.
+func (c *FileCache) Clear() error {
+	entries, err := filepath.Glob(filepath.Join(c.Dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := os.Remove(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) cacheKey(url string) string {
+	key := SortedParams(url)
+	if c.PublicKey != "" && c.SecretKey != "" {
+		key += "#" + c.PublicKey
+	}
+	return key
+}
@@ -0,0 +1,459 @@
+package cftest
+
+import (
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+)
+
+// Dataset is the in-memory content served by Server. Blog entries carry
+// their HTML Content and Comments, which are split across blogEntry.view,
+// blogEntry.comments and /blog/entry/{id} the same way Codeforces does.
+type Dataset struct {
+	Users             []*codeforces.User
+	Friends           map[string][]string
+	BlogEntries       []*codeforces.BlogEntry
+	Contests          []*codeforces.Contest
+	Problems          []*codeforces.Problem
+	ProblemStatistics []*codeforces.ProblemStatistics
+	Submissions       []*codeforces.Submission
+	Hacks             map[int][]*codeforces.Hack
+	RatingChanges     []*codeforces.RatingChange
+	Standings         map[int]*codeforces.Standings
+	RecentActions     []*codeforces.RecentAction
+}
+
+// Server is a fake Codeforces backed by a Dataset. When PublicKey and
+// SecretKey are set, signed requests are verified and user.friends
+// requires authentication.
+type Server struct {
+	*httptest.Server
+	PublicKey string
+	SecretKey string
+	// Latency delays every response, simulating a slow network.
+	Latency time.Duration
+
+	mu        sync.Mutex
+	data      *Dataset
+	calls     map[string]int
+	callLimit int
+}
+
+func NewServer(data *Dataset) *Server {
+	if data == nil {
+		data = new(Dataset)
+	}
+
+	s := &Server{data: data, calls: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client returns a client pointed at the server with no rate limiting and
+// near-instant retries.
+func (s *Server) Client() *codeforces.Client {
+	client := codeforces.NewClient()
+	client.BaseURL = s.URL
+	client.HTTPClient = s.Server.Client()
+	client.PublicKey = s.PublicKey
+	client.SecretKey = s.SecretKey
+	client.Limiter = nil
+	client.Retry = codeforces.RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	return client
+}
+
+// Update runs fn with exclusive access to the dataset.
+func (s *Server) Update(fn func(data *Dataset)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn(s.data)
+}
+
+// FailWithCallLimit makes the next n API calls answer "Call limit exceeded".
+func (s *Server) FailWithCallLimit(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.callLimit = n
+}
+
+// Calls returns how many times method (e.g. "blogEntry.view" or "blog")
+// has been requested, including failed attempts.
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[method]
+}
+
+type apiResponse struct {
+	Status  string `json:"status"`
+	Result  any    `json:"result,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, code int, response apiResponse) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeFailed(w http.ResponseWriter, code int, comment string) {
+	writeJSON(w, code, apiResponse{Status: "FAILED", Comment: comment})
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Latency > 0 {
+		select {
+		case <-time.After(s.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, ok := strings.CutPrefix(r.URL.Path, "/blog/entry/"); ok {
+		s.calls["blog"]++
+		s.serveBlogPage(w, id)
+		return
+	}
+
+	method, ok := strings.CutPrefix(r.URL.Path, "/api/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	s.calls[method]++
+
+	if s.callLimit > 0 {
+		s.callLimit--
+		writeFailed(w, http.StatusServiceUnavailable, "Call limit exceeded")
+		return
+	}
+
+	query := r.URL.Query()
+	authenticated := false
+	if query.Has("apiKey") || query.Has("apiSig") {
+		if comment := s.verifySignature(method, r.URL.RawQuery); comment != "" {
+			writeFailed(w, http.StatusBadRequest, comment)
+			return
+		}
+		authenticated = true
+	}
+
+	result, code, comment := s.call(method, query, authenticated)
+	if comment != "" {
+		writeFailed(w, code, comment)
+		return
+	}
+	writeJSON(w, http.StatusOK, apiResponse{Status: "OK", Result: result})
+}
+
+func (s *Server) verifySignature(method, rawQuery string) string {
+	if s.PublicKey == "" || s.SecretKey == "" {
+		return "apiKey: Incorrect API key"
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "Illegal query"
+	}
+	if query.Get("apiKey") != s.PublicKey {
+		return "apiKey: Incorrect API key"
+	}
+	if _, err := strconv.ParseInt(query.Get("time"), 10, 64); err != nil {
+		return "time: Field should contain time"
+	}
+
+	sig := query.Get("apiSig")
+	if len(sig) < 6 {
+		return "apiSig: Incorrect signature"
+	}
+
+	params := []string{}
+	for _, param := range strings.Split(rawQuery, "&") {
+		if !strings.HasPrefix(param, "apiSig=") {
+			params = append(params, param)
+		}
+	}
+	sort.Strings(params)
+
+	expected := fmt.Sprintf("%s%x", sig[:6], sha512.Sum512([]byte(fmt.Sprintf("%s/%s?%s#%s", sig[:6], method, strings.Join(params, "&"), s.SecretKey))))
+	if sig != expected {
+		return "apiSig: Incorrect signature"
+	}
+
+	return ""
+}
+
+func (s *Server) serveBlogPage(w http.ResponseWriter, id string) {
+	blogEntry := s.findBlogEntry(id)
+	if blogEntry == nil {
+		http.NotFound(w, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html;charset=UTF-8")
+	fmt.Fprintf(w, `<!DOCTYPE html><html><head><title>%s - Codeforces</title></head><body><div id="pageContent"><div class="topic"><div class="content"><div class="ttypography">%s</div></div></div></div></body></html>`, html.EscapeString(blogEntry.Title), blogEntry.Content)
+}
+
+func (s *Server) findBlogEntry(id string) *codeforces.BlogEntry {
+	for _, blogEntry := range s.data.BlogEntries {
+		if strconv.Itoa(blogEntry.ID) == id {
+			return blogEntry
+		}
+	}
+	return nil
+}
+
+func (s *Server) findUser(handle string) *codeforces.User {
+	for _, user := range s.data.Users {
+		if strings.EqualFold(user.Handle, handle) {
+			return user
+		}
+	}
+	return nil
+}
+
+func (s *Server) findContest(id string) *codeforces.Contest {
+	for _, contest := range s.data.Contests {
+		if strconv.Itoa(contest.ID) == id {
+			return contest
+		}
+	}
+	return nil
+}
+
+func page[T any](items []T, query url.Values) []T {
+	from, _ := strconv.Atoi(query.Get("from"))
+	if from < 1 {
+		from = 1
+	}
+	if from > len(items) {
+		return []T{}
+	}
+	items = items[from-1:]
+
+	if count, err := strconv.Atoi(query.Get("count")); err == nil && count < len(items) {
+		items = items[:count]
+	}
+	return items
+}
+
+func (s *Server) call(method string, query url.Values, authenticated bool) (any, int, string) {
+	switch method {
+	case "blogEntry.view":
+		blogEntry := s.findBlogEntry(query.Get("blogEntryId"))
+		if blogEntry == nil {
+			return nil, http.StatusBadRequest, fmt.Sprintf("blogEntryId: Blog entry with id %s not found", query.Get("blogEntryId"))
+		}
+		view := *blogEntry
+		view.Content, view.Comments = "", nil
+		return view, 0, ""
+
+	case "blogEntry.comments":
+		blogEntry := s.findBlogEntry(query.Get("blogEntryId"))
+		if blogEntry == nil {
+			return nil, http.StatusBadRequest, fmt.Sprintf("blogEntryId: Blog entry with id %s not found", query.Get("blogEntryId"))
+		}
+		return append([]codeforces.Comment{}, blogEntry.Comments...), 0, ""
+
+	case "contest.hacks":
+		if s.findContest(query.Get("contestId")) == nil {
+			return nil, http.StatusBadRequest, fmt.Sprintf("contestId: Contest with id %s not found", query.Get("contestId"))
+		}
+		contestID, _ := strconv.Atoi(query.Get("contestId"))
+		return append([]*codeforces.Hack{}, s.data.Hacks[contestID]...), 0, ""
+
+	case "contest.list":
+		gym := query.Get("gym") == "true"
+		contests := []*codeforces.Contest{}
+		for _, contest := range s.data.Contests {
+			if contest.IsGym() == gym {
+				contests = append(contests, contest)
+			}
+		}
+		return contests, 0, ""
+
+	case "contest.ratingChanges":
+		contestID, _ := strconv.Atoi(query.Get("contestId"))
+		if s.findContest(query.Get("contestId")) == nil {
+			return nil, http.StatusBadRequest, fmt.Sprintf("contestId: Contest with id %d not found", contestID)
+		}
+		ratingChanges := []*codeforces.RatingChange{}
+		for _, ratingChange := range s.data.RatingChanges {
+			if ratingChange.ContestID == contestID {
+				ratingChanges = append(ratingChanges, ratingChange)
+			}
+		}
+		return ratingChanges, 0, ""
+
+	case "contest.standings":
+		contest := s.findContest(query.Get("contestId"))
+		if contest == nil {
+			return nil, http.StatusBadRequest, fmt.Sprintf("contestId: Contest with id %s not found", query.Get("contestId"))
+		}
+		if contest.Phase == "BEFORE" {
+			return nil, http.StatusBadRequest, fmt.Sprintf("contestId: Contest with id %d has not started", contest.ID)
+		}
+		standings, ok := s.data.Standings[contest.ID]
+		if !ok {
+			standings = &codeforces.Standings{Contest: *contest}
+		}
+		return codeforces.Standings{Contest: standings.Contest, Problems: standings.Problems, Rows: page(standings.Rows, query)}, 0, ""
+
+	case "contest.status":
+		contestID, _ := strconv.Atoi(query.Get("contestId"))
+		if s.findContest(query.Get("contestId")) == nil {
+			return nil, http.StatusBadRequest, fmt.Sprintf("contestId: Contest with id %d not found", contestID)
+		}
+		return page(s.filterSubmissions(contestID, query.Get("handle")), query), 0, ""
+
+	case "problemset.problems":
+		problems := []*codeforces.Problem{}
+		statistics := []*codeforces.ProblemStatistics{}
+		tags := strings.Split(query.Get("tags"), ";")
+		for i, problem := range s.data.Problems {
+			if problem.ProblemsetName != query.Get("problemsetName") || (query.Get("tags") != "" && !hasAllTags(problem.Tags, tags)) {
+				continue
+			}
+			problems = append(problems, problem)
+			if i < len(s.data.ProblemStatistics) {
+				statistics = append(statistics, s.data.ProblemStatistics[i])
+			}
+		}
+		return map[string]any{"problems": problems, "problemStatistics": statistics}, 0, ""
+
+	case "problemset.recentStatus":
+		count, err := strconv.Atoi(query.Get("count"))
+		if err != nil || count < 1 || count > 1000 {
+			return nil, http.StatusBadRequest, "count: Field should contain value between 1 and 1000"
+		}
+		submissions := s.filterSubmissions(0, "")
+		if count < len(submissions) {
+			submissions = submissions[:count]
+		}
+		return submissions, 0, ""
+
+	case "recentActions":
+		actions := s.data.RecentActions
+		if maxCount, err := strconv.Atoi(query.Get("maxCount")); err == nil && maxCount < len(actions) {
+			actions = actions[:maxCount]
+		}
+		return append([]*codeforces.RecentAction{}, actions...), 0, ""
+
+	case "user.blogEntries":
+		if s.findUser(query.Get("handle")) == nil {
+			return nil, http.StatusBadRequest, fmt.Sprintf("handle: User with handle %s not found", query.Get("handle"))
+		}
+		blogEntries := []codeforces.BlogEntry{}
+		for _, blogEntry := range s.data.BlogEntries {
+			if strings.EqualFold(blogEntry.AuthorHandle, query.Get("handle")) {
+				view := *blogEntry
+				view.Content, view.Comments = "", nil
+				blogEntries = append(blogEntries, view)
+			}
+		}
+		return blogEntries, 0, ""
+
+	case "user.friends":
+		if !authenticated {
+			return nil, http.StatusBadRequest, "You have to be authenticated to use this method"
+		}
+		return append([]string{}, s.data.Friends[query.Get("handle")]...), 0, ""
+
+	case "user.info":
+		users := []*codeforces.User{}
+		for _, handle := range strings.Split(query.Get("handles"), ";") {
+			user := s.findUser(handle)
+			if user == nil {
+				return nil, http.StatusBadRequest, fmt.Sprintf("handles: User with handle %s not found", handle)
+			}
+			users = append(users, user)
+		}
+		return users, 0, ""
+
+	case "user.ratedList":
+		users := []*codeforces.User{}
+		for _, user := range s.data.Users {
+			if user.Rating > 0 {
+				users = append(users, user)
+			}
+		}
+		return users, 0, ""
+
+	case "user.rating":
+		if s.findUser(query.Get("handle")) == nil {
+			return nil, http.StatusBadRequest, fmt.Sprintf("handle: User with handle %s not found", query.Get("handle"))
+		}
+		ratingChanges := []*codeforces.RatingChange{}
+		for _, ratingChange := range s.data.RatingChanges {
+			if strings.EqualFold(ratingChange.Handle, query.Get("handle")) {
+				ratingChanges = append(ratingChanges, ratingChange)
+			}
+		}
+		return ratingChanges, 0, ""
+
+	case "user.status":
+		if s.findUser(query.Get("handle")) == nil {
+			return nil, http.StatusBadRequest, fmt.Sprintf("handle: User with handle %s not found", query.Get("handle"))
+		}
+		return page(s.filterSubmissions(0, query.Get("handle")), query), 0, ""
+	}
+
+	return nil, http.StatusNotFound, fmt.Sprintf("Method %s is not supported", method)
+}
+
+func (s *Server) filterSubmissions(contestID int, handle string) []*codeforces.Submission {
+	submissions := []*codeforces.Submission{}
+	for _, submission := range s.data.Submissions {
+		if contestID != 0 && submission.ContestID != contestID {
+			continue
+		}
+		if handle != "" && !partyHasMember(submission.Author, handle) {
+			continue
+		}
+		submissions = append(submissions, submission)
+	}
+	return submissions
+}
+
+func partyHasMember(party codeforces.Party, handle string) bool {
+	for _, member := range party.Members {
+		if strings.EqualFold(member.Handle, handle) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAllTags(problemTags, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, problemTag := range problemTags {
+			if problemTag == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,157 @@
+// Package cftest contains helpers for testing code that talks to Codeforces
+// without depending on the live site.
+package cftest
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type Mode int
+
+const (
+	ModeReplay Mode = iota
+	ModeRecord
+)
+
+// ModeFromEnv returns ModeRecord when CF_RECORD is set to a non-empty value
+// other than "0", and ModeReplay otherwise.
+func ModeFromEnv() Mode {
+	if value := os.Getenv("CF_RECORD"); value != "" && value != "0" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+type Fixture struct {
+	URL         string `json:"url"`
+	StatusCode  int    `json:"statusCode"`
+	ContentType string `json:"contentType"`
+	Body        string `json:"body"`
+}
+
+// Transport is an http.RoundTripper that records responses into Dir in
+// ModeRecord and serves them back from Dir in ModeReplay.
+type Transport struct {
+	Dir  string
+	Mode Mode
+	Base http.RoundTripper
+}
+
+func NewTransport(dir string, mode Mode) *Transport {
+	return &Transport{Dir: dir, Mode: mode, Base: http.DefaultTransport}
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._=-]+`)
+
+// CanonicalURL drops the host and the authentication parameters so that
+// signed and unsigned requests share the same fixture.
+func CanonicalURL(req *http.Request) string {
+	query := req.URL.Query()
+	query.Del("apiKey")
+	query.Del("time")
+	query.Del("apiSig")
+
+	url := strings.TrimPrefix(req.URL.Path, "/")
+	if encoded := query.Encode(); encoded != "" {
+		url += "?" + encoded
+	}
+	return url
+}
+
+func (t *Transport) FixturePath(req *http.Request) string {
+	canonical := CanonicalURL(req)
+
+	name := strings.Trim(unsafeChars.ReplaceAllString(canonical, "_"), "_")
+	if len(name) > 120 {
+		name = fmt.Sprintf("%s_%x", name[:100], sha1.Sum([]byte(canonical)))
+	}
+	return filepath.Join(t.Dir, name+".json")
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Mode == ModeRecord {
+		return t.record(req)
+	}
+	return t.replay(req)
+}
+
+func (t *Transport) replay(req *http.Request) (*http.Response, error) {
+	path := t.FixturePath(req)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no fixture for %s (run with CF_RECORD=1 to record it): %w", CanonicalURL(req), err)
+	}
+
+	fixture := new(Fixture)
+	if err = json.Unmarshal(data, fixture); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+
+	return fixture.Response(req), nil
+}
+
+func (t *Transport) record(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := &Fixture{
+		URL:         CanonicalURL(req),
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(body),
+	}
+	data, err := json.MarshalIndent(fixture, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(t.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(t.FixturePath(req), data, 0o644); err != nil {
+		return nil, err
+	}
+
+	return fixture.Response(req), nil
+}
+
+func (f *Fixture) Response(req *http.Request) *http.Response {
+	header := make(http.Header)
+	if f.ContentType != "" {
+		header.Set("Content-Type", f.ContentType)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.StatusCode, http.StatusText(f.StatusCode)),
+		StatusCode:    f.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewBufferString(f.Body)),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,239 @@
+package codeforces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const DefaultBaseURL = "https://codeforces.com"
+const DefaultUserAgent = "Codeforces-Analyzer"
+const DefaultTimeout = 30 * time.Second
+const DefaultAPIConcurrency = 4
+const DefaultHTMLConcurrency = 2
+
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	PublicKey  string
+	SecretKey  string
+	UserAgent  string
+	Lang       string
+	Limiter    *RateLimiter
+	Retry      RetryPolicy
+
+	// APISlots and HTMLSlots cap concurrent API calls and blog page scrapes
+	// separately, on top of the shared rate limiter.
+	APISlots  *ConcurrencyLimit
+	HTMLSlots *ConcurrencyLimit
+
+	// Cache is consulted before every non-streaming API call. CachePolicy
+	// decides the TTL of each result and defaults to DefaultCachePolicy.
+	Cache       Cache
+	CachePolicy CachePolicy
+}
+
+// DefaultClient is used by the package-level functions. It is configured
+// from the environment once, when the package is initialized.
+var DefaultClient = NewClientFromEnv()
+
+func NewClient() *Client {
+	return &Client{
+		BaseURL:    DefaultBaseURL,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+		UserAgent:  DefaultUserAgent,
+		Limiter:    DefaultLimiter,
+		Retry:      DefaultRetryPolicy,
+		APISlots:   NewConcurrencyLimit(DefaultAPIConcurrency),
+		HTMLSlots:  NewConcurrencyLimit(DefaultHTMLConcurrency),
+	}
+}
+
+func NewClientFromEnv() *Client {
+	client := NewClient()
+	client.PublicKey = os.Getenv("CF_PUBLIC_KEY")
+	client.SecretKey = os.Getenv("CF_SECRET_KEY")
+	if dir := os.Getenv("CF_CACHE_DIR"); dir != "" {
+		cache, err := NewFileCache(dir)
+		if err != nil {
+			log.Printf("codeforces: response cache disabled: %v", err)
+		} else {
+			client.Cache = cache
+		}
+	}
+
+	return client
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+func (c *Client) baseURL() string {
+	if c.BaseURL == "" {
+		return DefaultBaseURL
+	}
+	return strings.TrimRight(c.BaseURL, "/")
+}
+
+func (c *Client) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	return c.httpClient().Do(req)
+}
+
+func (c *Client) signURL(url string) string {
+	if c.PublicKey == "" || c.SecretKey == "" {
+		return url
+	}
+
+	if url[len(url)-1] != '?' {
+		url += "&"
+	}
+	url += fmt.Sprintf("apiKey=%s&time=%d", c.PublicKey, time.Now().Unix())
+	url += fmt.Sprintf("&apiSig=%s", GenerateAPISig(url, c.SecretKey))
+
+	return url
+}
+
+func (c *Client) apiURL(path string) (string, string) {
+	url := c.baseURL() + "/api/" + path
+	if !strings.Contains(url, "?") {
+		url += "?"
+	}
+	if c.Lang != "" {
+		if url[len(url)-1] != '?' {
+			url += "&"
+		}
+		url += fmt.Sprintf("lang=%s", c.Lang)
+	}
+
+	return strings.SplitN(path, "?", 2)[0], url
+}
+
+// GetRequest calls the API method described by path (e.g. "user.info?handles=tourist")
+// and returns the raw "result" field of the response.
+func (c *Client) GetRequest(ctx context.Context, path string) ([]byte, error) {
+	method, url := c.apiURL(path)
+
+	key := c.cacheKey(url)
+	if c.Cache != nil && !cacheBypassed(ctx) {
+		if result, ok := c.Cache.Get(key); ok {
+			return result, nil
+		}
+	}
+
+	var result []byte
+	err := c.withRetry(ctx, c.APISlots, func() (err error) {
+		result, err = c.getRequest(ctx, method, c.signURL(url))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Cache != nil {
+		policy := c.CachePolicy
+		if policy == nil {
+			policy = DefaultCachePolicy
+		}
+		if ttl := policy(method, result); ttl != 0 {
+			// A failed write only costs a future round trip.
+			_ = c.Cache.Set(key, result, ttl)
+		}
+	}
+
+	return result, nil
+}
+
+func (c *Client) getRequest(ctx context.Context, method, url string) ([]byte, error) {
+	resp, err := c.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseResponse(method, resp, body)
+}
+
+func parseResponse(method string, resp *http.Response, body []byte) ([]byte, error) {
+	response := struct {
+		Status  string          `json:"status"`
+		Result  json.RawMessage `json:"result"`
+		Comment string          `json:"comment"`
+	}{}
+	if err := json.Unmarshal(body, &response); err != nil || response.Status == "" {
+		if resp.StatusCode != http.StatusOK {
+			return nil, &HTTPStatusError{URL: resp.Request.URL.Path, StatusCode: resp.StatusCode}
+		}
+		if err == nil {
+			err = fmt.Errorf("missing status field")
+		}
+		return nil, &MalformedResponseError{Err: err}
+	}
+
+	if response.Status != "OK" {
+		return nil, newAPIError(method, response.Status, response.Comment)
+	}
+
+	return response.Result, nil
+}
+
+func (c *Client) GetBlogEntryContents(ctx context.Context, blogEntryID int) (string, error) {
+	var content string
+	err := c.withRetry(ctx, c.HTMLSlots, func() (err error) {
+		content, err = c.getBlogEntryContents(ctx, blogEntryID)
+		return err
+	})
+
+	return content, err
+}
+
+func (c *Client) getBlogEntryContents(ctx context.Context, blogEntryID int) (string, error) {
+	url := fmt.Sprintf("%s/blog/entry/%d", c.baseURL(), blogEntryID)
+	if c.Lang != "" {
+		url += fmt.Sprintf("?locale=%s", c.Lang)
+	}
+
+	resp, err := c.get(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusNotFound {
+			return "", ErrBlogEntryNotFound
+		}
+		return "", &HTTPStatusError{URL: resp.Request.URL.Path, StatusCode: resp.StatusCode}
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return doc.Find(".ttypography").First().Html()
+}
@@ -0,0 +1,38 @@
+package codeforces
+
+import "context"
+
+// ConcurrencyLimit caps how many requests of one kind are in flight at once.
+// A nil *ConcurrencyLimit imposes no limit.
+type ConcurrencyLimit struct {
+	slots chan struct{}
+}
+
+func NewConcurrencyLimit(n int) *ConcurrencyLimit {
+	if n < 1 {
+		n = 1
+	}
+
+	return &ConcurrencyLimit{slots: make(chan struct{}, n)}
+}
+
+func (l *ConcurrencyLimit) Acquire(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *ConcurrencyLimit) Release() {
+	if l == nil {
+		return
+	}
+
+	<-l.slots
+}
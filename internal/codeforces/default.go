@@ -0,0 +1,179 @@
+package codeforces
+
+import "context"
+
+func GetRequest(path string) ([]byte, error) {
+	return GetRequestContext(context.Background(), path)
+}
+
+func GetRequestContext(ctx context.Context, path string) ([]byte, error) {
+	return DefaultClient.GetRequest(ctx, path)
+}
+
+func (blogEntry *BlogEntry) GetComments() error {
+	return blogEntry.GetCommentsContext(context.Background())
+}
+
+func (blogEntry *BlogEntry) GetCommentsContext(ctx context.Context) (err error) {
+	blogEntry.Comments, err = DefaultClient.GetBlogEntryComments(ctx, blogEntry.ID)
+	return err
+}
+
+func (blogEntry *BlogEntry) GetContents() error {
+	return blogEntry.GetContentsContext(context.Background())
+}
+
+func (blogEntry *BlogEntry) GetContentsContext(ctx context.Context) (err error) {
+	blogEntry.Content, err = DefaultClient.GetBlogEntryContents(ctx, blogEntry.ID)
+	return err
+}
+
+func GetBlogEntry(blogEntryID int) (*BlogEntry, error) {
+	return GetBlogEntryContext(context.Background(), blogEntryID)
+}
+
+func GetBlogEntryContext(ctx context.Context, blogEntryID int) (*BlogEntry, error) {
+	return DefaultClient.GetBlogEntry(ctx, blogEntryID)
+}
+
+func GetBlogEntryView(blogEntryID int) (*BlogEntry, error) {
+	return GetBlogEntryViewContext(context.Background(), blogEntryID)
+}
+
+func GetBlogEntryViewContext(ctx context.Context, blogEntryID int) (*BlogEntry, error) {
+	return DefaultClient.GetBlogEntryView(ctx, blogEntryID)
+}
+
+func (contest *Contest) GetHacks() ([]*Hack, error) {
+	return contest.GetHacksContext(context.Background())
+}
+
+func (contest *Contest) GetHacksContext(ctx context.Context) ([]*Hack, error) {
+	return DefaultClient.GetContestHacks(ctx, contest.ID)
+}
+
+func GetContestList(gym bool) ([]*Contest, error) {
+	return GetContestListContext(context.Background(), gym)
+}
+
+func GetContestListContext(ctx context.Context, gym bool) ([]*Contest, error) {
+	return DefaultClient.GetContestList(ctx, gym)
+}
+
+func (contest *Contest) GetRatingChanges() ([]*RatingChange, error) {
+	return contest.GetRatingChangesContext(context.Background())
+}
+
+func (contest *Contest) GetRatingChangesContext(ctx context.Context) ([]*RatingChange, error) {
+	return DefaultClient.GetContestRatingChanges(ctx, contest.ID)
+}
+
+func (contest *Contest) GetStandings(from, count int, handles []string, room int, showUnofficial bool) (*Standings, error) {
+	return contest.GetStandingsContext(context.Background(), from, count, handles, room, showUnofficial)
+}
+
+func (contest *Contest) GetStandingsContext(ctx context.Context, from, count int, handles []string, room int, showUnofficial bool) (*Standings, error) {
+	return DefaultClient.GetContestStandings(ctx, contest.ID, StandingsOptions{
+		From:           from,
+		Count:          count,
+		Handles:        handles,
+		Room:           room,
+		ShowUnofficial: showUnofficial,
+	})
+}
+
+func (contest *Contest) GetStatus(from, count int, handle string) ([]*Submission, error) {
+	return contest.GetStatusContext(context.Background(), from, count, handle)
+}
+
+func (contest *Contest) GetStatusContext(ctx context.Context, from, count int, handle string) ([]*Submission, error) {
+	return DefaultClient.GetContestStatus(ctx, contest.ID, StatusOptions{From: from, Count: count, Handle: handle})
+}
+
+func GetProblems(tags []string, problemsetName string) ([]*Problem, []*ProblemStatistics, error) {
+	return GetProblemsContext(context.Background(), tags, problemsetName)
+}
+
+func GetProblemsContext(ctx context.Context, tags []string, problemsetName string) ([]*Problem, []*ProblemStatistics, error) {
+	return DefaultClient.GetProblems(ctx, tags, problemsetName)
+}
+
+func GetRecentStatus(count int, problemsetName string) ([]*Submission, error) {
+	return GetRecentStatusContext(context.Background(), count, problemsetName)
+}
+
+func GetRecentStatusContext(ctx context.Context, count int, problemsetName string) ([]*Submission, error) {
+	return DefaultClient.GetRecentStatus(ctx, count, problemsetName)
+}
+
+func GetRecentActions(maxCount int) ([]*RecentAction, error) {
+	return GetRecentActionsContext(context.Background(), maxCount)
+}
+
+func GetRecentActionsContext(ctx context.Context, maxCount int) ([]*RecentAction, error) {
+	return DefaultClient.GetRecentActions(ctx, maxCount)
+}
+
+func (user *User) GetBlogEntries() ([]*BlogEntry, error) {
+	return user.GetBlogEntriesContext(context.Background())
+}
+
+func (user *User) GetBlogEntriesContext(ctx context.Context) ([]*BlogEntry, error) {
+	return DefaultClient.GetUserBlogEntries(ctx, user.Handle)
+}
+
+func (user *User) GetFriends(onlyOnline bool) ([]string, error) {
+	return user.GetFriendsContext(context.Background(), onlyOnline)
+}
+
+func (user *User) GetFriendsContext(ctx context.Context, onlyOnline bool) ([]string, error) {
+	return DefaultClient.GetUserFriends(ctx, user.Handle, onlyOnline)
+}
+
+func (user *User) GetInfo() (*User, error) {
+	return user.GetInfoContext(context.Background())
+}
+
+func (user *User) GetInfoContext(ctx context.Context) (*User, error) {
+	return DefaultClient.GetUserInfo(ctx, user.Handle)
+}
+
+func GetUsersInfo(handles []string) ([]*User, error) {
+	return GetUsersInfoContext(context.Background(), handles)
+}
+
+func GetUsersInfoContext(ctx context.Context, handles []string) ([]*User, error) {
+	return DefaultClient.GetUsersInfo(ctx, handles, true)
+}
+
+func (contest *Contest) GetRatedList(activeOnly, includeRetired bool) ([]*User, error) {
+	return contest.GetRatedListContext(context.Background(), activeOnly, includeRetired)
+}
+
+func (contest *Contest) GetRatedListContext(ctx context.Context, activeOnly, includeRetired bool) ([]*User, error) {
+	return DefaultClient.GetRatedList(ctx, contest.ID, activeOnly, includeRetired)
+}
+
+func GetGlobalRatedList(activeOnly, includeRetired bool) ([]*User, error) {
+	return GetGlobalRatedListContext(context.Background(), activeOnly, includeRetired)
+}
+
+func GetGlobalRatedListContext(ctx context.Context, activeOnly, includeRetired bool) ([]*User, error) {
+	return DefaultClient.GetRatedList(ctx, 0, activeOnly, includeRetired)
+}
+
+func (user *User) GetRating() ([]*RatingChange, error) {
+	return user.GetRatingContext(context.Background())
+}
+
+func (user *User) GetRatingContext(ctx context.Context) ([]*RatingChange, error) {
+	return DefaultClient.GetUserRating(ctx, user.Handle)
+}
+
+func (user *User) GetStatus(from, count int) ([]*Submission, error) {
+	return user.GetStatusContext(context.Background(), from, count)
+}
+
+func (user *User) GetStatusContext(ctx context.Context, from, count int) ([]*Submission, error) {
+	return DefaultClient.GetUserStatus(ctx, user.Handle, from, count)
+}
@@ -0,0 +1,113 @@
+package codeforces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+)
+
+var (
+	ErrHandleNotFound           = errors.New("codeforces: handle not found")
+	ErrBlogEntryNotFound        = errors.New("codeforces: blog entry not found")
+	ErrContestNotFound          = errors.New("codeforces: contest not found")
+	ErrContestNotStarted        = errors.New("codeforces: contest has not started")
+	ErrAuthenticationRequired   = errors.New("codeforces: authentication required")
+	ErrRatingChangesUnavailable = errors.New("codeforces: rating changes are unavailable")
+	ErrCallLimitExceeded        = errors.New("codeforces: call limit exceeded")
+)
+
+var apiErrorPatterns = []struct {
+	pattern *regexp.Regexp
+	err     error
+}{
+	{regexp.MustCompile(`(?i)call limit exceeded`), ErrCallLimitExceeded},
+	{regexp.MustCompile(`(?i)user with handle .* not found`), ErrHandleNotFound},
+	{regexp.MustCompile(`(?i)blog entry with id .* not found`), ErrBlogEntryNotFound},
+	{regexp.MustCompile(`(?i)contest with id .* not found`), ErrContestNotFound},
+	{regexp.MustCompile(`(?i)contest with id .* has not started`), ErrContestNotStarted},
+	{regexp.MustCompile(`(?i)rating changes are unavailable`), ErrRatingChangesUnavailable},
+	{regexp.MustCompile(`(?i)(have to be authenticated|apikey|apisig)`), ErrAuthenticationRequired},
+}
+
+// APIError is returned when Codeforces answers with a status other than "OK".
+// It unwraps to one of the Err* sentinels when the comment is recognized.
+type APIError struct {
+	Method  string
+	Status  string
+	Comment string
+	Err     error
+}
+
+func newAPIError(method, status, comment string) *APIError {
+	apiErr := &APIError{Method: method, Status: status, Comment: comment}
+	for _, p := range apiErrorPatterns {
+		if p.pattern.MatchString(comment) {
+			apiErr.Err = p.err
+			break
+		}
+	}
+
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf(`codeforces API method %s returned status %s with error message "%s"`, e.Method, e.Status, e.Comment)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatusError is returned when Codeforces answers with a non-200 status
+// and a body that is not a regular API response.
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("codeforces returned HTTP status %d for %s", e.StatusCode, e.URL)
+}
+
+// MalformedResponseError is returned when a response body can't be decoded.
+type MalformedResponseError struct {
+	Err error
+}
+
+func (e *MalformedResponseError) Error() string {
+	return fmt.Sprintf("codeforces returned a malformed response: %s", e.Err)
+}
+
+func (e *MalformedResponseError) Unwrap() error {
+	return e.Err
+}
+
+func unmarshal(data []byte, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return &MalformedResponseError{Err: err}
+	}
+	return nil
+}
+
+// IsTransient reports whether err is worth retrying: call limit errors,
+// 5xx responses and network timeouts, including http.Client.Timeout. Callers
+// check their own context to tell its deadline apart from a timeout.
+func IsTransient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, ErrCallLimitExceeded) {
+		return true
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == 429
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
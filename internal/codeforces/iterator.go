@@ -0,0 +1,110 @@
+package codeforces
+
+import "context"
+
+const DefaultChunkSize = 1000
+
+// Iterator walks a paginated API result chunk by chunk. Offset reports the
+// 1-based position of the next row, which can be saved and passed back as
+// the starting offset to resume an interrupted walk.
+type Iterator[T any] struct {
+	fetch     func(ctx context.Context, from, count int) ([]*T, error)
+	chunkSize int
+	offset    int
+	buffer    []*T
+	current   *T
+	exhausted bool
+	err       error
+}
+
+type SubmissionIterator = Iterator[Submission]
+type StandingsIterator = Iterator[RanklistRow]
+
+func newIterator[T any](from, chunkSize int, fetch func(ctx context.Context, from, count int) ([]*T, error)) *Iterator[T] {
+	if from < 1 {
+		from = 1
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	return &Iterator[T]{fetch: fetch, chunkSize: chunkSize, offset: from}
+}
+
+// Next advances to the next row, fetching a new chunk when needed. It returns
+// false when the rows are exhausted or an error occurred; check Err.
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	if len(it.buffer) == 0 {
+		if it.exhausted {
+			it.current = nil
+			return false
+		}
+
+		chunk, err := it.fetch(ctx, it.offset, it.chunkSize)
+		if err != nil {
+			it.err = err
+			it.current = nil
+			return false
+		}
+		it.buffer = chunk
+		it.exhausted = len(chunk) < it.chunkSize
+		if len(chunk) == 0 {
+			it.current = nil
+			return false
+		}
+	}
+
+	it.current, it.buffer = it.buffer[0], it.buffer[1:]
+	it.offset++
+	return true
+}
+
+func (it *Iterator[T]) Value() *T {
+	return it.current
+}
+
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+func (it *Iterator[T]) Offset() int {
+	return it.offset
+}
+
+// ContestSubmissions iterates over contest.status starting at options.From.
+// options.Count is ignored; rows are fetched chunkSize at a time.
+func (c *Client) ContestSubmissions(contestID int, options StatusOptions, chunkSize int) *SubmissionIterator {
+	return newIterator(options.From, chunkSize, func(ctx context.Context, from, count int) ([]*Submission, error) {
+		options.From, options.Count = from, count
+		return c.GetContestStatus(ctx, contestID, options)
+	})
+}
+
+// UserSubmissions iterates over user.status starting at from.
+func (c *Client) UserSubmissions(handle string, from, chunkSize int) *SubmissionIterator {
+	return newIterator(from, chunkSize, func(ctx context.Context, from, count int) ([]*Submission, error) {
+		return c.GetUserStatus(ctx, handle, from, count)
+	})
+}
+
+// ContestStandings iterates over the rows of contest.standings starting at
+// options.From. options.Count is ignored; rows are fetched chunkSize at a time.
+func (c *Client) ContestStandings(contestID int, options StandingsOptions, chunkSize int) *StandingsIterator {
+	return newIterator(options.From, chunkSize, func(ctx context.Context, from, count int) ([]*RanklistRow, error) {
+		options.From, options.Count = from, count
+		standings, err := c.GetContestStandings(ctx, contestID, options)
+		if err != nil {
+			return nil, err
+		}
+
+		rows := make([]*RanklistRow, len(standings.Rows))
+		for i := range standings.Rows {
+			rows[i] = &standings.Rows[i]
+		}
+		return rows, nil
+	})
+}
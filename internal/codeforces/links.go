@@ -0,0 +1,224 @@
+package codeforces
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type LinkKind string
+
+const (
+	LinkProblem    LinkKind = "problem"
+	LinkContest    LinkKind = "contest"
+	LinkSubmission LinkKind = "submission"
+	LinkBlog       LinkKind = "blog"
+	LinkProfile    LinkKind = "profile"
+)
+
+// Problem and contest link types.
+const (
+	LinkTypeContest    = "contest"
+	LinkTypeGym        = "gym"
+	LinkTypeProblemset = "problemset"
+	LinkTypeACMSGURU   = "acmsguru"
+	LinkTypeEdu        = "edu"
+)
+
+// Domains serving Codeforces, including mirrors. Any subdomain of them
+// (www, m1, m2, ...) is accepted as well.
+var Domains = []string{"codeforces.com", "codeforces.ml", "codeforces.ru", "codeforc.es"}
+
+// Link is a parsed link to a Codeforces page. Only the fields relevant to
+// its Kind are set: problems and contests have a Type ("contest", "gym",
+// "problemset", "acmsguru" or "edu") and a ContestID, problems an Index,
+// submissions a SubmissionID, blogs a BlogID and profiles a Handle.
+type Link struct {
+	Kind         LinkKind
+	Type         string
+	ContestID    int
+	Index        string
+	SubmissionID int
+	BlogID       int
+	Handle       string
+
+	// Raw is the link as it was found; Path is its canonical path.
+	Raw  string
+	Path string
+}
+
+// URL returns the canonical URL of the link on DefaultBaseURL.
+func (l Link) URL() string {
+	return DefaultBaseURL + l.Path
+}
+
+var ErrUnknownLink = errors.New("codeforces: not a known Codeforces link")
+
+var idRegex = regexp.MustCompile(`^[1-9]\d{0,8}$`)
+var eduIDRegex = regexp.MustCompile(`^\d{1,9}$`)
+var indexRegex = regexp.MustCompile(`^([A-Za-z]\d{0,2}|\d{1,4})$`)
+var handleRegex = regexp.MustCompile(`^[A-Za-z0-9_.\-]{1,24}$`)
+
+func isCodeforcesHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range Domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseID(s string) (int, bool) {
+	if !idRegex.MatchString(s) {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(s)
+	return id, err == nil
+}
+
+// ParseLink parses an absolute, scheme-less or relative link to Codeforces.
+// Query strings and fragments are ignored. It returns ErrUnknownLink if the
+// link doesn't point to a problem, contest, submission, blog entry or
+// profile on Codeforces.
+func ParseLink(raw string) (Link, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Opaque != "" || u.User != nil {
+		return Link{}, ErrUnknownLink
+	}
+
+	host, path := u.Hostname(), u.Path
+	switch {
+	case u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https":
+		return Link{}, ErrUnknownLink
+	case u.Scheme != "" || host != "":
+		if !isCodeforcesHost(host) {
+			return Link{}, ErrUnknownLink
+		}
+	case !strings.HasPrefix(path, "/"):
+		// "codeforces.com/contest/1" parses as a relative path.
+		host, path, _ = strings.Cut(path, "/")
+		if !isCodeforcesHost(host) {
+			return Link{}, ErrUnknownLink
+		}
+		path = "/" + path
+	}
+
+	link, ok := parsePath(strings.Split(strings.Trim(path, "/"), "/"))
+	if !ok {
+		return Link{}, ErrUnknownLink
+	}
+	link.Raw = raw
+
+	return link, nil
+}
+
+func parsePath(segments []string) (Link, bool) {
+	link := Link{}
+	ok := true
+	switch {
+	case len(segments) >= 2 && (segments[0] == LinkTypeContest || segments[0] == LinkTypeGym):
+		link.Type = segments[0]
+		link.ContestID, ok = parseID(segments[1])
+		switch {
+		case len(segments) == 4 && segments[2] == "problem":
+			link.Kind, link.Index = LinkProblem, segments[3]
+		case len(segments) == 4 && segments[2] == "submission":
+			var submissionOK bool
+			link.Kind = LinkSubmission
+			link.SubmissionID, submissionOK = parseID(segments[3])
+			ok = ok && submissionOK
+			link.Path = fmt.Sprintf("/%s/%d/submission/%d", link.Type, link.ContestID, link.SubmissionID)
+		default:
+			link.Kind = LinkContest
+			link.Path = fmt.Sprintf("/%s/%d", link.Type, link.ContestID)
+		}
+
+	case len(segments) == 4 && segments[0] == "problemset" && (segments[1] == "problem" || segments[1] == "gymProblem"):
+		link.Kind, link.Type, link.Index = LinkProblem, LinkTypeProblemset, segments[3]
+		if segments[1] == "gymProblem" {
+			link.Type = LinkTypeGym
+		}
+		link.ContestID, ok = parseID(segments[2])
+
+	case len(segments) == 5 && segments[0] == "problemsets" && segments[1] == LinkTypeACMSGURU && segments[2] == "problem":
+		link.Kind, link.Type, link.Index = LinkProblem, LinkTypeACMSGURU, segments[4]
+		link.ContestID, ok = parseID(segments[3])
+
+	case len(segments) == 11 && segments[0] == "edu" && segments[1] == "course" && segments[3] == "lesson" && segments[6] == "practice" && segments[7] == "contest" && segments[9] == "problem":
+		link.Kind, link.Type, link.Index = LinkProblem, LinkTypeEdu, segments[10]
+		link.ContestID, ok = parseID(segments[8])
+		ok = ok && eduIDRegex.MatchString(segments[2]) && eduIDRegex.MatchString(segments[4]) && eduIDRegex.MatchString(segments[5])
+
+	case len(segments) == 3 && segments[0] == "blog" && segments[1] == "entry":
+		link.Kind = LinkBlog
+		link.BlogID, ok = parseID(segments[2])
+		link.Path = fmt.Sprintf("/blog/entry/%d", link.BlogID)
+
+	case len(segments) == 2 && segments[0] == "profile":
+		link.Kind, link.Handle = LinkProfile, segments[1]
+		ok = handleRegex.MatchString(link.Handle)
+		link.Path = "/profile/" + link.Handle
+
+	default:
+		return Link{}, false
+	}
+
+	if link.Kind == LinkProblem {
+		ok = ok && indexRegex.MatchString(link.Index)
+		link.Index = strings.ToUpper(link.Index)
+		switch link.Type {
+		case LinkTypeProblemset:
+			link.Path = fmt.Sprintf("/problemset/problem/%d/%s", link.ContestID, link.Index)
+		case LinkTypeACMSGURU:
+			link.Path = fmt.Sprintf("/problemsets/acmsguru/problem/%d/%s", link.ContestID, link.Index)
+		case LinkTypeEdu:
+			link.Path = fmt.Sprintf("/edu/course/%s/lesson/%s/%s/practice/contest/%d/problem/%s", segments[2], segments[4], segments[5], link.ContestID, link.Index)
+		default:
+			link.Path = fmt.Sprintf("/%s/%d/problem/%s", link.Type, link.ContestID, link.Index)
+		}
+	}
+
+	return link, ok
+}
+
+var absoluteLinkRegex = regexp.MustCompile(`(?i)((https?:)?//)?([a-z0-9-]+\.)*(codeforces\.(com|ml|ru)|codeforc\.es)(:\d+)?/[^\s"'<>]*`)
+var relativeLinkRegex = regexp.MustCompile(`(?i)href\s*=\s*["'](/[^/"'\s][^"'\s]*)["']`)
+
+// FindLinks returns the Codeforces links in content, absolute ones in plain
+// text or attributes and relative ones in href attributes, in the order
+// they appear. Unrecognized links are skipped.
+func FindLinks(content string) []Link {
+	type match struct {
+		start int
+		raw   string
+	}
+	matches := []match{}
+
+	for _, index := range absoluteLinkRegex.FindAllStringIndex(content, -1) {
+		// Skip matches that start inside another word, like notcodeforces.com.
+		if index[0] > 0 && strings.ContainsAny(content[index[0]-1:index[0]], "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789.-") {
+			continue
+		}
+		matches = append(matches, match{start: index[0], raw: strings.TrimRight(content[index[0]:index[1]], ".,;:!?)]}")})
+	}
+	for _, index := range relativeLinkRegex.FindAllStringSubmatchIndex(content, -1) {
+		matches = append(matches, match{start: index[2], raw: content[index[2]:index[3]]})
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	links := []Link{}
+	for _, match := range matches {
+		if link, err := ParseLink(match.raw); err == nil {
+			links = append(links, link)
+		}
+	}
+
+	return links
+}
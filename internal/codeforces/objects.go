@@ -84,14 +84,14 @@ type Contest struct {
 }
 
 type Party struct {
-	ContestID        int    `json:"contestId"`
-	Members          []User `json:"members"`
-	ParticipantType  string `json:"participantType"`
-	TeamID           int    `json:"teamId"`
-	TeamName         string `json:"teamName"`
-	Ghost            bool   `json:"ghost"`
-	Room             int    `json:"room"`
-	StartTimeSeconds int    `json:"startTimeSeconds"`
+	ContestID        int      `json:"contestId"`
+	Members          []Member `json:"members"`
+	ParticipantType  string   `json:"participantType"`
+	TeamID           int      `json:"teamId"`
+	TeamName         string   `json:"teamName"`
+	Ghost            bool     `json:"ghost"`
+	Room             int      `json:"room"`
+	StartTimeSeconds int      `json:"startTimeSeconds"`
 }
 
 type Member struct {
@@ -99,6 +99,25 @@ type Member struct {
 	Name   string `json:"name"`
 }
 
+const (
+	ParticipantContestant       = "CONTESTANT"
+	ParticipantPractice         = "PRACTICE"
+	ParticipantVirtual          = "VIRTUAL"
+	ParticipantManager          = "MANAGER"
+	ParticipantOutOfCompetition = "OUT_OF_COMPETITION"
+)
+
+const ProblemsetACMSGURU = "acmsguru"
+
+// Gym contests have IDs starting from 100000.
+func IsGymContest(contestID int) bool {
+	return contestID >= 100000
+}
+
+func (contest *Contest) IsGym() bool {
+	return IsGymContest(contest.ID)
+}
+
 type Problem struct {
 	ContestID      int      `json:"contestId"`
 	ProblemsetName string   `json:"problemsetName"`
@@ -131,7 +150,7 @@ type Submission struct {
 	CreationTimeSeconds int     `json:"creationTimeSeconds"`
 	RelativeTimeSeconds int     `json:"relativeTimeSeconds"`
 	Problem             Problem `json:"problem"`
-	Author              User    `json:"author"`
+	Author              Party   `json:"author"`
 	ProgrammingLanguage string  `json:"programmingLanguage"`
 	Verdict             string  `json:"verdict"`
 	Testset             string  `json:"testset"`
@@ -174,9 +193,13 @@ type Standings struct {
 }
 
 type ReferencedProblem struct {
-	BlogID      int      `json:"blogId"`
-	ProblemType string   `json:"problemType"`
-	ProblemID   int      `json:"problemId"`
-	Index       string   `json:"index"`
-	Tags        []string `json:"tags"`
+	BlogID        int      `json:"blogId"`
+	ProblemType   string   `json:"problemType"`
+	ProblemID     int      `json:"problemId"`
+	Index         string   `json:"index"`
+	Tags          []string `json:"tags"`
+	Snippet       string   `json:"snippet"`
+	CommentID     int      `json:"commentId"`
+	CommentAuthor string   `json:"commentAuthor"`
+	Stale         bool     `json:"stale"`
 }
@@ -1,18 +1,12 @@
 package codeforces
 
 import (
+	"context"
 	"crypto/sha512"
-	"encoding/json"
 	"fmt"
-	"io"
 	"math/rand"
-	"net/http"
-	"os"
 	"sort"
 	"strings"
-	"time"
-
-	"github.com/PuerkitoBio/goquery"
 )
 
 func SortedParams(url string) string {
@@ -32,175 +26,185 @@ func GenerateAPISig(url, secret string) string {
 	return fmt.Sprintf("%d%x", rnd, SHA512.Sum(nil))
 }
 
-func GetRequest(url string) ([]byte, error) {
-	public := os.Getenv("CF_PUBLIC_KEY")
-	secret := os.Getenv("CF_SECRET_KEY")
-	if public != "" && secret != "" {
-		if url[len(url)-1] != '?' {
-			url += "&"
-		}
-		url += fmt.Sprintf("apiKey=%s&time=%d", public, time.Now().Unix())
-		url += fmt.Sprintf("&apiSig=%s", GenerateAPISig(url, secret))
-	}
-
-	resp, err := http.Get(url)
+func (c *Client) GetBlogEntryComments(ctx context.Context, blogEntryID int) ([]Comment, error) {
+	resp, err := c.GetRequest(ctx, fmt.Sprintf("blogEntry.comments?blogEntryId=%d", blogEntryID))
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	response := struct {
-		Status  string          `json:"status"`
-		Result  json.RawMessage `json:"result"`
-		Comment string          `json:"comment"`
-	}{}
-	if err = json.Unmarshal(body, &response); err != nil {
+	comments := []Comment{}
+	if err = unmarshal(resp, &comments); err != nil {
 		return nil, err
 	}
 
-	if response.Status != "OK" {
-		return nil, fmt.Errorf(`codeforces API returned status %s with error message "%s"`, response.Status, response.Comment)
-	}
-
-	return response.Result, nil
+	return comments, nil
 }
 
-func (blogEntry *BlogEntry) GetComments() error {
-	resp, err := GetRequest(fmt.Sprintf("https://codeforces.com/api/blogEntry.comments?blogEntryId=%d", blogEntry.ID))
+// GetBlogEntryView fetches only the blogEntry.view metadata, without scraping
+// the blog page or fetching comments.
+func (c *Client) GetBlogEntryView(ctx context.Context, blogEntryID int) (*BlogEntry, error) {
+	resp, err := c.GetRequest(ctx, fmt.Sprintf("blogEntry.view?blogEntryId=%d", blogEntryID))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err = json.Unmarshal(resp, &blogEntry.Comments); err != nil {
-		return err
+	blogEntry := new(BlogEntry)
+	if err = unmarshal(resp, &blogEntry); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return blogEntry, nil
 }
 
-func GetBlogEntry(blogEntryID int) (*BlogEntry, error) {
-	resp, err := GetRequest(fmt.Sprintf("https://codeforces.com/api/blogEntry.view?blogEntryId=%d", blogEntryID))
+func (c *Client) GetBlogEntry(ctx context.Context, blogEntryID int) (*BlogEntry, error) {
+	blogEntry, err := c.GetBlogEntryView(ctx, blogEntryID)
 	if err != nil {
 		return nil, err
 	}
 
-	blogEntry := new(BlogEntry)
-	if err = json.Unmarshal(resp, &blogEntry); err != nil {
-		return nil, err
-	}
-
-	if err = blogEntry.GetContents(); err != nil {
+	if blogEntry.Content, err = c.GetBlogEntryContents(ctx, blogEntry.ID); err != nil {
 		return nil, err
 	}
-	if err = blogEntry.GetComments(); err != nil {
+	if blogEntry.Comments, err = c.GetBlogEntryComments(ctx, blogEntry.ID); err != nil {
 		return nil, err
 	}
 
 	return blogEntry, nil
 }
 
-func (contest *Contest) GetHacks() ([]*Hack, error) {
-	resp, err := GetRequest(fmt.Sprintf("https://codeforces.com/api/contest.hacks?contestId=%d", contest.ID))
+func (c *Client) GetContestHacks(ctx context.Context, contestID int) ([]*Hack, error) {
+	resp, err := c.GetRequest(ctx, fmt.Sprintf("contest.hacks?contestId=%d", contestID))
 	if err != nil {
 		return nil, err
 	}
 
 	hacks := []*Hack{}
-	if err = json.Unmarshal(resp, &hacks); err != nil {
+	if err = unmarshal(resp, &hacks); err != nil {
 		return nil, err
 	}
 
 	return hacks, nil
 }
 
-func GetContestList(gym bool) ([]*Contest, error) {
-	resp, err := GetRequest(fmt.Sprintf("https://codeforces.com/api/contest.list?gym=%t", gym))
+func (c *Client) GetContestList(ctx context.Context, gym bool) ([]*Contest, error) {
+	resp, err := c.GetRequest(ctx, fmt.Sprintf("contest.list?gym=%t", gym))
 	if err != nil {
 		return nil, err
 	}
 
 	contests := []*Contest{}
-	if err = json.Unmarshal(resp, &contests); err != nil {
+	if err = unmarshal(resp, &contests); err != nil {
 		return nil, err
 	}
 
 	return contests, nil
 }
 
-func (contest *Contest) GetRatingChanges() ([]*RatingChange, error) {
-	resp, err := GetRequest(fmt.Sprintf("https://codeforces.com/api/contest.ratingChanges?contestId=%d", contest.ID))
+func (c *Client) GetContestRatingChanges(ctx context.Context, contestID int) ([]*RatingChange, error) {
+	if IsGymContest(contestID) {
+		return nil, fmt.Errorf("%w: contest %d is a gym contest", ErrRatingChangesUnavailable, contestID)
+	}
+
+	resp, err := c.GetRequest(ctx, fmt.Sprintf("contest.ratingChanges?contestId=%d", contestID))
 	if err != nil {
 		return nil, err
 	}
 
 	ratingChanges := []*RatingChange{}
-	if err = json.Unmarshal(resp, &ratingChanges); err != nil {
+	if err = unmarshal(resp, &ratingChanges); err != nil {
 		return nil, err
 	}
 
 	return ratingChanges, nil
 }
 
-func (contest *Contest) GetStandings(from, count int, handles []string, room int, showUnofficial bool) (*Standings, error) {
-	url := fmt.Sprintf("https://codeforces.com/api/contest.standings?contestId=%d&showUnofficial=%t", contest.ID, showUnofficial)
-	if from > 1 {
-		url += fmt.Sprintf("&from=%d", from)
+type StandingsOptions struct {
+	From             int
+	Count            int
+	Handles          []string
+	Room             int
+	ShowUnofficial   bool
+	ParticipantTypes []string
+	AsManager        bool
+}
+
+func (c *Client) GetContestStandings(ctx context.Context, contestID int, options StandingsOptions) (*Standings, error) {
+	url := fmt.Sprintf("contest.standings?contestId=%d&showUnofficial=%t", contestID, options.ShowUnofficial)
+	if options.From > 1 {
+		url += fmt.Sprintf("&from=%d", options.From)
 	}
-	if count > 0 {
-		url += fmt.Sprintf("&count=%d", count)
+	if options.Count > 0 {
+		url += fmt.Sprintf("&count=%d", options.Count)
+	}
+	if len(options.Handles) > 0 {
+		url += fmt.Sprintf("&handles=%s", strings.Join(options.Handles, ";"))
 	}
-	if len(handles) > 0 {
-		url += fmt.Sprintf("&handles=%s", strings.Join(handles, ";"))
+	if options.Room > 0 {
+		url += fmt.Sprintf("&room=%d", options.Room)
 	}
-	if room > 0 {
-		url += fmt.Sprintf("&room=%d", room)
+	if len(options.ParticipantTypes) > 0 {
+		url += fmt.Sprintf("&participantTypes=%s", strings.Join(options.ParticipantTypes, ","))
+	}
+	if options.AsManager {
+		url += "&asManager=true"
 	}
 
-	resp, err := GetRequest(url)
+	resp, err := c.GetRequest(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
 	standings := new(Standings)
-	if err = json.Unmarshal(resp, &standings); err != nil {
+	if err = unmarshal(resp, &standings); err != nil {
 		return nil, err
 	}
 
 	return standings, nil
 }
 
-func (contest *Contest) GetStatus(from, count int, handle string) ([]*Submission, error) {
-	url := fmt.Sprintf("https://codeforces.com/api/contest.status?contestId=%d", contest.ID)
-	if from > 1 {
-		url += fmt.Sprintf("&from=%d", from)
+type StatusOptions struct {
+	From      int
+	Count     int
+	Handle    string
+	AsManager bool
+}
+
+func contestStatusPath(contestID int, options StatusOptions) string {
+	url := fmt.Sprintf("contest.status?contestId=%d", contestID)
+	if options.From > 1 {
+		url += fmt.Sprintf("&from=%d", options.From)
 	}
-	if count > 0 {
-		url += fmt.Sprintf("&count=%d", count)
+	if options.Count > 0 {
+		url += fmt.Sprintf("&count=%d", options.Count)
 	}
-	if handle != "" {
-		url += fmt.Sprintf("&handle=%s", handle)
+	if options.Handle != "" {
+		url += fmt.Sprintf("&handle=%s", options.Handle)
 	}
+	if options.AsManager {
+		url += "&asManager=true"
+	}
+
+	return url
+}
 
-	resp, err := GetRequest(url)
+func (c *Client) GetContestStatus(ctx context.Context, contestID int, options StatusOptions) ([]*Submission, error) {
+	url := contestStatusPath(contestID, options)
+
+	resp, err := c.GetRequest(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
 	submissions := []*Submission{}
-	if err = json.Unmarshal(resp, &submissions); err != nil {
+	if err = unmarshal(resp, &submissions); err != nil {
 		return nil, err
 	}
 
 	return submissions, nil
 }
 
-func GetProblems(tags []string, problemsetName string) ([]*Problem, []*ProblemStatistics, error) {
-	url := "https://codeforces.com/api/problemset.problems?"
+func (c *Client) GetProblems(ctx context.Context, tags []string, problemsetName string) ([]*Problem, []*ProblemStatistics, error) {
+	url := "problemset.problems?"
 	if len(tags) > 0 {
 		url += fmt.Sprintf("tags=%s", strings.Join(tags, ";"))
 	}
@@ -211,7 +215,7 @@ func GetProblems(tags []string, problemsetName string) ([]*Problem, []*ProblemSt
 		url += fmt.Sprintf("problemsetName=%s", problemsetName)
 	}
 
-	resp, err := GetRequest(url)
+	resp, err := c.GetRequest(ctx, url)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -220,180 +224,172 @@ func GetProblems(tags []string, problemsetName string) ([]*Problem, []*ProblemSt
 		Problems          []*Problem           `json:"problems"`
 		ProblemStatistics []*ProblemStatistics `json:"problemStatistics"`
 	}{}
-	if err = json.Unmarshal(resp, &response); err != nil {
+	if err = unmarshal(resp, &response); err != nil {
 		return nil, nil, err
 	}
 
 	return response.Problems, response.ProblemStatistics, nil
 }
 
-func GetRecentStatus(maxCount int, problemsetName string) ([]*Submission, error) {
-	url := "https://codeforces.com/api/recentActions?"
-	if maxCount > 0 {
-		url += fmt.Sprintf("maxCount=%d", maxCount)
+func (c *Client) GetRecentStatus(ctx context.Context, count int, problemsetName string) ([]*Submission, error) {
+	if count <= 0 || count > 1000 {
+		return nil, fmt.Errorf("recent status count must be between 1 and 1000, got %d", count)
 	}
+
+	url := fmt.Sprintf("problemset.recentStatus?count=%d", count)
 	if problemsetName != "" {
-		if url[len(url)-1] != '?' {
-			url += "&"
-		}
-		url += fmt.Sprintf("problemsetName=%s", problemsetName)
+		url += fmt.Sprintf("&problemsetName=%s", problemsetName)
 	}
 
-	resp, err := GetRequest(url)
+	resp, err := c.GetRequest(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
 	submissions := []*Submission{}
-	if err = json.Unmarshal(resp, &submissions); err != nil {
+	if err = unmarshal(resp, &submissions); err != nil {
 		return nil, err
 	}
 
 	return submissions, nil
 }
 
-func GetRecentActions(maxCount int) ([]*RecentAction, error) {
-	url := "https://codeforces.com/api/recentActions?"
+func (c *Client) GetRecentActions(ctx context.Context, maxCount int) ([]*RecentAction, error) {
+	url := "recentActions?"
 	if maxCount > 0 {
 		url += fmt.Sprintf("maxCount=%d", maxCount)
 	}
 
-	resp, err := GetRequest(url)
+	resp, err := c.GetRequest(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
 	actions := []*RecentAction{}
-	if err = json.Unmarshal(resp, &actions); err != nil {
+	if err = unmarshal(resp, &actions); err != nil {
 		return nil, err
 	}
 
 	return actions, nil
 }
 
-func (blogEntry *BlogEntry) GetContents() error {
-	resp, err := http.Get(fmt.Sprintf("https://codeforces.com/blog/entry/%d", blogEntry.ID))
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		return err
-	}
-
-	content, err := doc.Find(".ttypography").First().Html()
-	if err != nil {
-		return err
-	}
-
-	blogEntry.Content = content
-
-	return nil
-}
-
-func (user *User) GetBlogEntries() ([]*BlogEntry, error) {
-	resp, err := GetRequest(fmt.Sprintf("https://codeforces.com/api/user.blogEntries?handle=%s", user.Handle))
+func (c *Client) GetUserBlogEntries(ctx context.Context, handle string) ([]*BlogEntry, error) {
+	resp, err := c.GetRequest(ctx, fmt.Sprintf("user.blogEntries?handle=%s", handle))
 	if err != nil {
 		return nil, err
 	}
 
 	blogEntries := []*BlogEntry{}
-	if err = json.Unmarshal(resp, &blogEntries); err != nil {
+	if err = unmarshal(resp, &blogEntries); err != nil {
 		return nil, err
 	}
 
 	return blogEntries, nil
 }
 
-func (user *User) GetFriends(onlyOnline bool) ([]string, error) {
-	resp, err := GetRequest(fmt.Sprintf("https://codeforces.com/api/user.friends?onlyOnline=%t&handle=%s", onlyOnline, user.Handle))
+func (c *Client) GetUserFriends(ctx context.Context, handle string, onlyOnline bool) ([]string, error) {
+	resp, err := c.GetRequest(ctx, fmt.Sprintf("user.friends?onlyOnline=%t&handle=%s", onlyOnline, handle))
 	if err != nil {
 		return nil, err
 	}
 
 	friends := []string{}
-	if err = json.Unmarshal(resp, &friends); err != nil {
+	if err = unmarshal(resp, &friends); err != nil {
 		return nil, err
 	}
 
 	return friends, nil
 }
 
-func (user *User) GetInfo() (*User, error) {
-	resp, err := GetRequest(fmt.Sprintf("https://codeforces.com/api/user.info?handles=%s", user.Handle))
+func (c *Client) GetUserInfo(ctx context.Context, handle string) (*User, error) {
+	users, err := c.GetUsersInfo(ctx, []string{handle}, true)
 	if err != nil {
 		return nil, err
 	}
-
-	users := []User{}
-	if err = json.Unmarshal(resp, &users); err != nil {
-		return nil, err
+	if len(users) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrHandleNotFound, handle)
 	}
 
-	return &users[0], nil
+	return users[0], nil
 }
 
-func GetUsersInfo(handles []string) ([]*User, error) {
-	resp, err := GetRequest(fmt.Sprintf("https://codeforces.com/api/user.info?handles=%s", strings.Join(handles, ";")))
+func (c *Client) GetUsersInfo(ctx context.Context, handles []string, checkHistoricHandles bool) ([]*User, error) {
+	resp, err := c.GetRequest(ctx, fmt.Sprintf("user.info?handles=%s&checkHistoricHandles=%t", strings.Join(handles, ";"), checkHistoricHandles))
 	if err != nil {
 		return nil, err
 	}
 
 	users := []*User{}
-	if err = json.Unmarshal(resp, &users); err != nil {
+	if err = unmarshal(resp, &users); err != nil {
 		return nil, err
 	}
 
 	return users, nil
 }
 
-func (contest *Contest) GetRatedList(activeOnly, includeRetired bool) ([]*User, error) {
-	url := fmt.Sprintf("https://codeforces.com/api/user.ratedList?activeOnly=%t&includeRetired=%t", activeOnly, includeRetired)
-	if contest.ID > 0 {
-		url += fmt.Sprintf("&contestId=%d", contest.ID)
+func ratedListPath(contestID int, activeOnly, includeRetired bool) string {
+	url := fmt.Sprintf("user.ratedList?activeOnly=%t&includeRetired=%t", activeOnly, includeRetired)
+	if contestID > 0 {
+		url += fmt.Sprintf("&contestId=%d", contestID)
 	}
 
-	resp, err := GetRequest(url)
+	return url
+}
+
+func (c *Client) GetRatedList(ctx context.Context, contestID int, activeOnly, includeRetired bool) ([]*User, error) {
+	url := ratedListPath(contestID, activeOnly, includeRetired)
+
+	resp, err := c.GetRequest(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
 	users := []*User{}
-	if err = json.Unmarshal(resp, &users); err != nil {
+	if err = unmarshal(resp, &users); err != nil {
 		return nil, err
 	}
 
 	return users, nil
 }
 
-func GetGlobalRatedList(activeOnly, includeRetired bool) ([]*User, error) {
-	url := fmt.Sprintf("https://codeforces.com/api/user.ratedList?activeOnly=%t&includeRetired=%t", activeOnly, includeRetired)
-
-	resp, err := GetRequest(url)
+func (c *Client) GetUserRating(ctx context.Context, handle string) ([]*RatingChange, error) {
+	resp, err := c.GetRequest(ctx, fmt.Sprintf("user.rating?handle=%s", handle))
 	if err != nil {
 		return nil, err
 	}
 
-	users := []*User{}
-	if err = json.Unmarshal(resp, &users); err != nil {
+	ratingChanges := []*RatingChange{}
+	if err = unmarshal(resp, &ratingChanges); err != nil {
 		return nil, err
 	}
 
-	return users, nil
+	return ratingChanges, nil
 }
 
-func (user *User) GetRating() ([]*RatingChange, error) {
-	resp, err := GetRequest(fmt.Sprintf("https://codeforces.com/api/user.rating?handle=%s", user.Handle))
+func userStatusPath(handle string, from, count int) string {
+	url := fmt.Sprintf("user.status?handle=%s", handle)
+	if from > 1 {
+		url += fmt.Sprintf("&from=%d", from)
+	}
+	if count > 0 {
+		url += fmt.Sprintf("&count=%d", count)
+	}
+
+	return url
+}
+
+func (c *Client) GetUserStatus(ctx context.Context, handle string, from, count int) ([]*Submission, error) {
+	url := userStatusPath(handle, from, count)
+
+	resp, err := c.GetRequest(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
-	ratingChanges := []*RatingChange{}
-	if err = json.Unmarshal(resp, &ratingChanges); err != nil {
+	submissions := []*Submission{}
+	if err = unmarshal(resp, &submissions); err != nil {
 		return nil, err
 	}
 
-	return ratingChanges, nil
+	return submissions, nil
 }
@@ -0,0 +1,59 @@
+package codeforces
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 5,
+	BaseDelay:  2 * time.Second,
+	MaxDelay:   time.Minute,
+}
+
+// Backoff returns the delay before the given retry attempt (starting at 0),
+// growing exponentially with full jitter and capped at MaxDelay unless it's
+// zero.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 0; i < attempt && (p.MaxDelay == 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func (c *Client) withRetry(ctx context.Context, slots *ConcurrencyLimit, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := slots.Acquire(ctx); err != nil {
+			return err
+		}
+		if err := c.Limiter.Wait(ctx); err != nil {
+			slots.Release()
+			return err
+		}
+
+		err := fn()
+		slots.Release()
+		if err == nil || ctx.Err() != nil || attempt >= c.Retry.MaxRetries || !IsTransient(err) {
+			return err
+		}
+
+		if err := sleep(ctx, c.Retry.Backoff(attempt)); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package codeforces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// StreamRequest calls the API method described by path and hands fn a decoder
+// positioned at each element of the "result" array in turn, so huge results
+// never have to be held in memory at once. fn must consume exactly one value.
+// Failures are only retried before the first element has been handed out.
+func (c *Client) StreamRequest(ctx context.Context, path string, fn func(dec *json.Decoder) error) error {
+	method, url := c.apiURL(path)
+
+	// The slot is held until the whole result has been consumed.
+	if err := c.APISlots.Acquire(ctx); err != nil {
+		return err
+	}
+	defer c.APISlots.Release()
+
+	var resp *http.Response
+	err := c.withRetry(ctx, nil, func() (err error) {
+		resp, err = c.openRequest(ctx, method, c.signURL(url))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return decodeStream(method, resp.Body, fn)
+}
+
+func (c *Client) openRequest(ctx context.Context, method, url string) (*http.Response, error) {
+	resp, err := c.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = parseResponse(method, resp, body); err != nil {
+		return nil, err
+	}
+	return nil, &HTTPStatusError{URL: resp.Request.URL.Path, StatusCode: resp.StatusCode}
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return &MalformedResponseError{Err: err}
+	}
+	if token != delim {
+		return &MalformedResponseError{Err: fmt.Errorf("expected %s, got %v", delim, token)}
+	}
+	return nil
+}
+
+func decodeStream(method string, body io.Reader, fn func(dec *json.Decoder) error) error {
+	dec := json.NewDecoder(body)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	var status, comment string
+	hasResult := false
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return &MalformedResponseError{Err: err}
+		}
+
+		if token == "result" && (status == "" || status == "OK") {
+			hasResult = true
+			if err = decodeResultArray(dec, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch token {
+		case "status":
+			err = dec.Decode(&status)
+		case "comment":
+			err = dec.Decode(&comment)
+		default:
+			err = dec.Decode(new(json.RawMessage))
+		}
+		if err != nil {
+			return &MalformedResponseError{Err: err}
+		}
+	}
+
+	if status != "OK" {
+		if status == "" {
+			return &MalformedResponseError{Err: fmt.Errorf("missing status field")}
+		}
+		return newAPIError(method, status, comment)
+	}
+	if !hasResult {
+		return &MalformedResponseError{Err: fmt.Errorf("missing result field")}
+	}
+	return nil
+}
+
+func decodeResultArray(dec *json.Decoder, fn func(dec *json.Decoder) error) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		if err := fn(dec); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+func streamResult[T any](ctx context.Context, c *Client, path string, fn func(item *T) error) error {
+	return c.StreamRequest(ctx, path, func(dec *json.Decoder) error {
+		item := new(T)
+		if err := dec.Decode(item); err != nil {
+			return &MalformedResponseError{Err: err}
+		}
+		return fn(item)
+	})
+}
+
+func (c *Client) StreamContestStatus(ctx context.Context, contestID int, options StatusOptions, fn func(submission *Submission) error) error {
+	return streamResult(ctx, c, contestStatusPath(contestID, options), fn)
+}
+
+func (c *Client) StreamContestHacks(ctx context.Context, contestID int, fn func(hack *Hack) error) error {
+	return streamResult(ctx, c, fmt.Sprintf("contest.hacks?contestId=%d", contestID), fn)
+}
+
+func (c *Client) StreamUserStatus(ctx context.Context, handle string, from, count int, fn func(submission *Submission) error) error {
+	return streamResult(ctx, c, userStatusPath(handle, from, count), fn)
+}
+
+func (c *Client) StreamRatedList(ctx context.Context, contestID int, activeOnly, includeRetired bool, fn func(user *User) error) error {
+	return streamResult(ctx, c, ratedListPath(contestID, activeOnly, includeRetired), fn)
+}
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"time"
+
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+)
+
+// CommentDiff is how a blog's comments changed since it was last crawled.
+type CommentDiff struct {
+	Added   []*codeforces.Comment
+	Edited  []*codeforces.Comment
+	Deleted []int
+}
+
+func (d CommentDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Edited) == 0 && len(d.Deleted) == 0
+}
+
+// Changed returns the added and edited comments.
+func (d CommentDiff) Changed() []*codeforces.Comment {
+	return append(append([]*codeforces.Comment{}, d.Added...), d.Edited...)
+}
+
+func hashComment(text string) string {
+	hash := sha1.Sum([]byte(text))
+	return hex.EncodeToString(hash[:])
+}
+
+// DiffComments compares comments, the current comments of a blog, with the
+// ones recorded for it by ID and text hash. A comment that was deleted and
+// shows up again, or was saved but never analyzed, counts as added.
+func DiffComments(blogID int, comments []codeforces.Comment) (CommentDiff, error) {
+	diff := CommentDiff{}
+	recorded, err := GetCommentHashes(blogID)
+	if err != nil {
+		return diff, err
+	}
+
+	seen := make(map[int]bool)
+	for i := range comments {
+		comment := &comments[i]
+		seen[comment.ID] = true
+
+		state, ok := recorded[comment.ID]
+		switch {
+		case !ok || state.Deleted || state.Hash == "":
+			diff.Added = append(diff.Added, comment)
+		case state.Hash != hashComment(comment.Text):
+			diff.Edited = append(diff.Edited, comment)
+		}
+	}
+	for commentID, state := range recorded {
+		if !seen[commentID] && !state.Deleted {
+			diff.Deleted = append(diff.Deleted, commentID)
+		}
+	}
+
+	return diff, nil
+}
+
+// RecordCommentDeletions records the deletions of diff and marks the
+// problem references of edited and deleted comments stale. References still
+// present in an edited comment are refreshed when it's analyzed again.
+func RecordCommentDeletions(blogID int, diff CommentDiff) error {
+	for _, comment := range diff.Edited {
+		if err := MarkCommentReferencesStale(blogID, comment.ID); err != nil {
+			return err
+		}
+	}
+	for _, commentID := range diff.Deleted {
+		if err := MarkCommentDeleted(blogID, commentID, time.Now()); err != nil {
+			return err
+		}
+		if err := MarkCommentReferencesStale(blogID, commentID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveCommentHashes records the hashes of the added and edited comments of
+// diff. It's called once they are analyzed, so an interrupted crawl
+// analyzes them again.
+func SaveCommentHashes(blogID int, diff CommentDiff) error {
+	for _, comment := range diff.Changed() {
+		if err := SaveCommentHash(blogID, comment, hashComment(comment.Text)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,23 +1,23 @@
 package internal
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"html"
 	"log"
-	"regexp"
-	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+	"github.com/PuerkitoBio/goquery"
 )
 
-const CodeforcesUrl = `((http|https)://)?(www.)?codeforces\.com`
-const problemUrlRegex = CodeforcesUrl + `/(([A-Za-z/]+/problem/\d+/[A-Za-z\d]+)|(contest/\d+/problem/[A-Za-z\d]+)|(gym/\d+/problem/[A-Za-z\d]+))`
-const blogUrlRegex = CodeforcesUrl + `/blog/entry/(\d+)`
-
-func UpdateProblemsFromAPI() error {
+func UpdateProblemsFromAPI(ctx context.Context) error {
 	log.Println("Updating problems from API...")
 
-	problems, problemStatistics, err := codeforces.GetProblems([]string{}, "")
+	problems, problemStatistics, err := codeforces.GetProblemsContext(ctx, []string{}, "")
 	if err != nil {
 		return err
 	}
@@ -32,120 +32,406 @@ func UpdateProblemsFromAPI() error {
 	return nil
 }
 
+// FindTagsForProblem returns the inferred tags of the problem at problemUrl
+// whose confidence reaches TagConfidenceThreshold.
 func FindTagsForProblem(problemUrl string, content string) []string {
-	return []string{}
+	tags := []string{}
+	for _, score := range InferTags(problemUrl, content) {
+		if score.Confidence >= TagConfidenceThreshold {
+			tags = append(tags, score.Tag)
+		}
+	}
+
+	return tags
 }
 
-func AnalyzeProblem(problemUrl string, blogID int, content string) error {
-	log.Printf("Analyzing problem %s...\n", problemUrl)
+// snippetBlocks are the elements whose text makes up the snippet of a
+// problem mention.
+const snippetBlocks = "p, li, blockquote, pre, td, h1, h2, h3, h4, h5, h6"
 
-	data := strings.Split(problemUrl, "/")
-	data = data[len(data)-4:]
-	if data[0] == "gym" || data[0] == "contest" {
-		data[1], data[2] = data[2], data[1]
-	}
-	data = append(data[:1], data[2:]...)
+// mentionsLink reports whether selection links to the page of link, in an
+// href attribute or as plain text.
+func mentionsLink(selection *goquery.Selection, link codeforces.Link) bool {
+	found := false
+	selection.Find("[href]").EachWithBreak(func(_ int, anchor *goquery.Selection) bool {
+		href, _ := anchor.Attr("href")
+		if parsed, err := codeforces.ParseLink(href); err == nil && parsed.Path == link.Path {
+			found = true
+		}
+		return !found
+	})
+
+	return found || strings.Contains(selection.Text(), html.UnescapeString(link.Raw))
+}
 
-	problemID, err := strconv.Atoi(data[1])
+// mentionSnippet returns the HTML and the text of the innermost paragraph,
+// list item or similar block of content that mentions link, or of the whole
+// content if there's no such block.
+func mentionSnippet(link codeforces.Link, content string) (string, string) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
 	if err != nil {
-		return err
+		return content, htmlToText(content)
+	}
+
+	var block *goquery.Selection
+	doc.Find(snippetBlocks).EachWithBreak(func(_ int, selection *goquery.Selection) bool {
+		if !mentionsLink(selection, link) {
+			return true
+		}
+
+		block = selection
+		innerMention := false
+		selection.Find(snippetBlocks).Each(func(_ int, inner *goquery.Selection) {
+			if mentionsLink(inner, link) {
+				innerMention = true
+			}
+		})
+		return innerMention
+	})
+
+	if block == nil {
+		return content, strings.TrimSpace(spaceRegex.ReplaceAllString(doc.Text(), " "))
 	}
 
+	html, _ := goquery.OuterHtml(block)
+	return html, strings.TrimSpace(spaceRegex.ReplaceAllString(block.Text(), " "))
+}
+
+// AnalyzeProblem saves a mention of the problem link in content, which is
+// the blog's content or, when comment is set, the comment's text.
+func AnalyzeProblem(link codeforces.Link, blogID int, content string, comment *codeforces.Comment) error {
+	log.Printf("Analyzing problem %s...\n", link.URL())
+
+	snippetHtml, snippet := mentionSnippet(link, content)
 	referenced := &codeforces.ReferencedProblem{
 		BlogID:      blogID,
-		ProblemType: data[0],
-		ProblemID:   problemID,
-		Index:       data[2],
-		Tags:        FindTagsForProblem(problemUrl, content),
+		ProblemType: link.Type,
+		ProblemID:   link.ContestID,
+		Index:       link.Index,
+		Tags:        FindTagsForProblem(link.Raw, snippetHtml),
+		Snippet:     snippet,
+	}
+	if comment != nil {
+		referenced.CommentID = comment.ID
+		referenced.CommentAuthor = comment.CommentatorHandle
 	}
 
 	return SaveReferencedProblem(referenced)
 }
 
-func AnalyzeProblemsOnBlog(blog *codeforces.BlogEntry) []int {
-	r := regexp.MustCompile(problemUrlRegex)
-	for _, match := range r.FindAllStringSubmatch(blog.Content, -1) {
-		if err := AnalyzeProblem(match[0], blog.ID, blog.Content); err != nil {
-			continue
-		}
-	}
-
+// analyzeLinks saves the problems mentioned in content and returns the IDs
+// of the blogs it links to.
+func analyzeLinks(blogID int, content string, comment *codeforces.Comment) []int {
 	blogIDs := make([]int, 0)
-	r = regexp.MustCompile(blogUrlRegex)
-	for _, match := range r.FindAllStringSubmatch(blog.Content, -1) {
-		blogID, err := strconv.Atoi(match[len(match)-1])
-		if err != nil {
-			continue
+	for _, link := range codeforces.FindLinks(content) {
+		switch link.Kind {
+		case codeforces.LinkProblem:
+			if err := AnalyzeProblem(link, blogID, content, comment); err != nil {
+				log.Printf("Error analyzing problem %s: %s\n", link.URL(), err)
+			}
+		case codeforces.LinkBlog:
+			blogIDs = append(blogIDs, link.BlogID)
 		}
-		blogIDs = append(blogIDs, blogID)
 	}
 
 	return blogIDs
 }
 
-func AnalyzeProblemsOnComments(blog *codeforces.BlogEntry) []int {
-	r := regexp.MustCompile(problemUrlRegex)
-	for _, comment := range blog.Comments {
-		for _, match := range r.FindAllStringSubmatch(comment.Text, -1) {
-			if err := AnalyzeProblem(match[0], blog.ID, blog.Content+`<div class="comment">`+comment.Text+`</div>`); err != nil {
-				continue
-			}
-		}
-	}
+func AnalyzeProblemsOnBlog(blog *codeforces.BlogEntry) []int {
+	return analyzeLinks(blog.ID, blog.Content, nil)
+}
 
+func AnalyzeProblemsOnComments(blog *codeforces.BlogEntry) []int {
 	blogIDs := make([]int, 0)
-	r = regexp.MustCompile(blogUrlRegex)
-	for _, comment := range blog.Comments {
-		for _, match := range r.FindAllStringSubmatch(comment.Text, -1) {
-			blogID, err := strconv.Atoi(match[len(match)-1])
-			if err != nil {
-				continue
-			}
-			blogIDs = append(blogIDs, blogID)
-		}
+	for i := range blog.Comments {
+		blogIDs = append(blogIDs, analyzeLinks(blog.ID, blog.Comments[i].Text, &blog.Comments[i])...)
 	}
 
 	return blogIDs
 }
 
-func CrawlBlogEntry(blogID int) error {
+// crawlBlog fetches, classifies and analyzes a single blog according to its
+// class's crawl policy, and returns it together with the IDs of the blogs
+// to follow.
+func (c *Crawler) crawlBlog(ctx context.Context, blogID int) (*codeforces.BlogEntry, []int, error) {
 	log.Printf("Crawling blog %d...\n", blogID)
 
-	blog, err := codeforces.GetBlogEntry(blogID)
+	blog, err := codeforces.GetBlogEntryViewContext(ctx, blogID)
 	if err != nil {
-		return err
-	}
-	if strings.Contains(strings.ToLower(blog.Title), "editorial") {
-		log.Printf("Skipping blog %d because it's an editorial...\n", blogID)
-		return nil
+		return nil, nil, err
 	}
 	lastVersion, err := GetBlogEntry(blogID)
 	if err != nil && err != sql.ErrNoRows {
-		return err
+		return nil, nil, err
+	}
+
+	contentChanged := lastVersion == nil || lastVersion.ModificationTimeSeconds < blog.ModificationTimeSeconds
+	if contentChanged {
+		if err := blog.GetContentsContext(ctx); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		blog.Content = lastVersion.Content
 	}
 
+	// The API has no cheaper way to learn whether comments changed, but only
+	// new and edited comments are analyzed.
+	if err := blog.GetCommentsContext(ctx); err != nil {
+		return nil, nil, err
+	}
+	diff, err := DiffComments(blogID, blog.Comments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !contentChanged && diff.Empty() {
+		log.Printf("Blog %d is unchanged...\n", blogID)
+		return blog, nil, nil
+	}
+
+	class := ClassifyBlog(blog)
+	policy := c.policy(class)
+	log.Printf("Blog %d is classified as %s...\n", blogID, class)
+
 	nextBlogs := make([]int, 0)
-	if lastVersion == nil || lastVersion.ModificationTimeSeconds < blog.ModificationTimeSeconds {
+	if contentChanged && policy.AnalyzeContent {
 		nextBlogs = AnalyzeProblemsOnBlog(blog)
 	}
-	if lastVersion == nil || len(lastVersion.Comments) < len(blog.Comments) {
-		nextBlogs = append(nextBlogs, AnalyzeProblemsOnComments(blog)...)
+	if err := RecordCommentDeletions(blogID, diff); err != nil {
+		return nil, nil, err
+	}
+	if policy.AnalyzeComments {
+		for _, comment := range diff.Changed() {
+			nextBlogs = append(nextBlogs, analyzeLinks(blogID, comment.Text, comment)...)
+		}
 	}
 
 	if err := SaveBlogEntry(blog); err != nil {
+		return nil, nil, err
+	}
+	if err := SaveCommentHashes(blogID, diff); err != nil {
+		return nil, nil, err
+	}
+
+	contestID := 0
+	if class == BlogEditorial {
+		contestID, _ = editorialContest(blog)
+		if contentChanged && policy.AnalyzeContent {
+			if err := AnalyzeEditorial(blog, contestID); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if err := SaveBlogClass(blogID, class, contestID); err != nil {
+		return nil, nil, err
+	}
+
+	if !policy.FollowLinks {
+		nextBlogs = nil
+	}
+
+	return blog, nextBlogs, nil
+}
+
+const DefaultMaxDepth = 10
+const DefaultWorkers = 4
+const DefaultDrainTimeout = 30 * time.Second
+const DefaultClaimTimeout = 15 * time.Minute
+
+// DefaultCrawlRetryPolicy retries blogs whose crawl failed with a transient
+// error, once the client has given up retrying the request itself.
+var DefaultCrawlRetryPolicy = codeforces.RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  time.Minute,
+	MaxDelay:   time.Hour,
+}
+
+// Crawler visits blogs from the crawl frontier stored in the database, so an
+// interrupted crawl resumes where it stopped. Blogs linked from a crawled
+// blog are enqueued one level deeper with the linking blog's rating as
+// their priority; blogs deeper than MaxDepth are not followed.
+//
+// Workers blogs are crawled concurrently. When the context passed to Run is
+// cancelled no new blogs are claimed, and blogs already in flight get up to
+// DrainTimeout to finish before they are put back into the frontier. Blogs
+// claimed more than ClaimTimeout ago and never finished are assumed to be
+// left over by a crashed crawler and are crawled again.
+//
+// Policies decide per blog class what is analyzed and defaults to
+// DefaultCrawlPolicies. Blogs that fail with a transient error go back into
+// the frontier to be retried by a later Run after Retry's backoff, up to
+// Retry.MaxRetries times; other failures are final. Run doesn't wait for
+// these retries, so they stay pending after it returns.
+type Crawler struct {
+	MaxDepth     int
+	Workers      int
+	DrainTimeout time.Duration
+	ClaimTimeout time.Duration
+	Policies     map[BlogClass]CrawlPolicy
+	Retry        codeforces.RetryPolicy
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	active int
+	err    error
+}
+
+func NewCrawler() *Crawler {
+	return &Crawler{
+		MaxDepth:     DefaultMaxDepth,
+		Workers:      DefaultWorkers,
+		DrainTimeout: DefaultDrainTimeout,
+		ClaimTimeout: DefaultClaimTimeout,
+		Retry:        DefaultCrawlRetryPolicy,
+	}
+}
+
+func (c *Crawler) policy(class BlogClass) CrawlPolicy {
+	if policy, ok := c.Policies[class]; ok {
+		return policy
+	}
+	return DefaultCrawlPolicies[class]
+}
+
+// Enqueue adds a seed blog to the frontier, even if it was visited before.
+func (c *Crawler) Enqueue(blogID, priority int) error {
+	return EnqueueBlog(blogID, 0, priority, true)
+}
+
+// Run crawls until the frontier is empty or ctx is done.
+func (c *Crawler) Run(ctx context.Context) error {
+	if err := ResetInterruptedFrontier(time.Now().Add(-c.ClaimTimeout)); err != nil {
 		return err
 	}
 
-	for _, nextBlogID := range nextBlogs {
-		if nextBlogID == blogID {
-			continue
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
+
+	c.cond = sync.NewCond(&c.mu)
+	c.active, c.err = 0, nil
+	stop := context.AfterFunc(ctx, func() {
+		time.AfterFunc(c.DrainTimeout, cancelWork)
+
+		c.mu.Lock()
+		c.cond.Broadcast()
+		c.mu.Unlock()
+	})
+	defer stop()
+
+	workers := c.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.work(ctx, workCtx)
+		}()
+	}
+	wg.Wait()
+
+	if c.err != nil && !errors.Is(c.err, context.Canceled) {
+		return c.err
+	}
+	return ctx.Err()
+}
+
+func (c *Crawler) work(ctx, workCtx context.Context) {
+	for {
+		entry := c.claim(ctx)
+		if entry == nil {
+			return
+		}
+
+		err := c.process(workCtx, entry)
+
+		c.mu.Lock()
+		c.active--
+		if err != nil && c.err == nil {
+			c.err = err
+		}
+		c.cond.Broadcast()
+		c.mu.Unlock()
+	}
+}
+
+// claim returns the next blog to crawl, waiting while the frontier is empty
+// but other workers may still enqueue links. It returns nil once the crawl
+// is over.
+func (c *Crawler) claim(ctx context.Context) *FrontierEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for {
+		if c.err != nil || ctx.Err() != nil {
+			return nil
 		}
 
-		err := CrawlBlogEntry(nextBlogID)
-		if err != nil {
-			log.Printf("Error crawling blog %d: %s\n", nextBlogID, err)
+		entry, err := NextFrontierEntry()
+		if err == nil {
+			c.active++
+			return entry
+		}
+		if err != sql.ErrNoRows {
+			c.err = err
+			c.cond.Broadcast()
+			return nil
 		}
+		if c.active == 0 {
+			c.cond.Broadcast()
+			return nil
+		}
+
+		c.cond.Wait()
 	}
+}
 
-	return nil
+func (c *Crawler) process(ctx context.Context, entry *FrontierEntry) error {
+	blog, nextBlogs, err := c.crawlBlog(ctx, entry.BlogID)
+	if ctx.Err() != nil {
+		if err := FinishFrontierEntry(entry.BlogID, FrontierPending, nil); err != nil {
+			return err
+		}
+		return ctx.Err()
+	}
+
+	switch {
+	case errors.Is(err, codeforces.ErrBlogEntryNotFound):
+		log.Printf("Skipping blog %d because it doesn't exist...\n", entry.BlogID)
+		return FinishFrontierEntry(entry.BlogID, FrontierSkipped, err)
+	case err != nil && codeforces.IsTransient(err) && entry.Attempts < c.Retry.MaxRetries:
+		delay := c.Retry.Backoff(entry.Attempts)
+		log.Printf("Error crawling blog %d, retrying in %s: %s\n", entry.BlogID, delay, err)
+		return RetryFrontierEntry(entry.BlogID, time.Now().Add(delay), err)
+	case err != nil:
+		log.Printf("Error crawling blog %d: %s\n", entry.BlogID, err)
+		return FinishFrontierEntry(entry.BlogID, FrontierFailed, err)
+	}
+
+	if c.MaxDepth <= 0 || entry.Depth < c.MaxDepth {
+		for _, nextBlogID := range nextBlogs {
+			if nextBlogID == entry.BlogID {
+				continue
+			}
+			if err := EnqueueBlog(nextBlogID, entry.Depth+1, blog.Rating, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	return FinishFrontierEntry(entry.BlogID, FrontierDone, nil)
+}
+
+// CrawlBlogEntry crawls blogID and every blog reachable from it. Errors on
+// individual blogs are logged and recorded in the frontier.
+func CrawlBlogEntry(ctx context.Context, blogID int) error {
+	crawler := NewCrawler()
+	if err := crawler.Enqueue(blogID, 0); err != nil {
+		return err
+	}
+
+	return crawler.Run(ctx)
 }
@@ -0,0 +1,275 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type ScheduledJob struct {
+	Name      string
+	Interval  time.Duration
+	LastRun   time.Time
+	NextRun   time.Time
+	LastError string
+}
+
+// Daemon keeps the database fresh: it polls recent actions, refreshes the
+// problemset, re-crawls high-rated blogs on a backoff schedule and drains
+// the crawl frontier in between. Job timestamps are persisted, so a
+// restarted daemon picks up its schedule where it stopped.
+type Daemon struct {
+	Crawler *Crawler
+
+	RecentActionsInterval time.Duration
+	RecentActionsCount    int
+	ProblemsInterval      time.Duration
+	RecrawlInterval       time.Duration
+	RecrawlMinRating      int
+	RecrawlBaseDelay      time.Duration
+	RecrawlMaxDelay       time.Duration
+}
+
+func NewDaemon() *Daemon {
+	return &Daemon{
+		Crawler:               NewCrawler(),
+		RecentActionsInterval: 5 * time.Minute,
+		RecentActionsCount:    100,
+		ProblemsInterval:      24 * time.Hour,
+		RecrawlInterval:       time.Hour,
+		RecrawlMinRating:      100,
+		RecrawlBaseDelay:      6 * time.Hour,
+		RecrawlMaxDelay:       30 * 24 * time.Hour,
+	}
+}
+
+func (d *Daemon) Jobs() []Job {
+	return []Job{
+		{Name: "recent-actions", Interval: d.RecentActionsInterval, Run: d.pollRecentActions},
+		{Name: "problems", Interval: d.ProblemsInterval, Run: UpdateProblemsFromAPI},
+		{Name: "recrawl", Interval: d.RecrawlInterval, Run: d.scheduleRecrawls},
+	}
+}
+
+func (d *Daemon) Schedule() ([]ScheduledJob, error) {
+	schedule := []ScheduledJob{}
+	for _, job := range d.Jobs() {
+		state, err := GetJobState(job.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		scheduled := ScheduledJob{Name: job.Name, Interval: job.Interval, NextRun: time.Now()}
+		if state != nil {
+			scheduled.LastRun = state.LastRun
+			scheduled.LastError = state.LastError
+			if !state.LastRun.IsZero() {
+				scheduled.NextRun = state.LastRun.Add(job.Interval)
+			}
+		}
+		schedule = append(schedule, scheduled)
+	}
+
+	return schedule, nil
+}
+
+// Run executes due jobs and crawls the frontier until ctx is done.
+func (d *Daemon) Run(ctx context.Context) error {
+	for {
+		schedule, err := d.Schedule()
+		if err != nil {
+			return err
+		}
+
+		next := time.Time{}
+		for i, job := range d.Jobs() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if !schedule[i].NextRun.After(time.Now()) {
+				log.Printf("Running job %s...\n", job.Name)
+				started := time.Now()
+				jobErr := job.Run(ctx)
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if jobErr != nil {
+					log.Printf("Error running job %s: %s\n", job.Name, jobErr)
+				}
+				if err := SaveJobState(job.Name, started, jobErr); err != nil {
+					return err
+				}
+				schedule[i].NextRun = started.Add(job.Interval)
+			}
+
+			if next.IsZero() || schedule[i].NextRun.Before(next) {
+				next = schedule[i].NextRun
+			}
+		}
+
+		if err := d.Crawler.Run(ctx); err != nil {
+			return err
+		}
+		if retryAt, err := NextFrontierRetry(); err == nil && retryAt.Before(next) {
+			next = retryAt
+		} else if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (d *Daemon) pollRecentActions(ctx context.Context) error {
+	state, err := GetJobState("recent-actions")
+	if err != nil {
+		return err
+	}
+
+	seeder := RecentActionsSeeder{MaxCount: d.RecentActionsCount}
+	if state != nil && !state.LastRun.IsZero() {
+		seeder.Since = state.LastRun.Unix()
+	}
+
+	seeded, err := seeder.Seed(ctx, d.Crawler)
+	log.Printf("Enqueued %d recently active blogs...\n", seeded)
+	return err
+}
+
+// scheduleRecrawls enqueues high-rated blogs whose re-crawl is due. A blog
+// that changed since its previous re-crawl goes back to RecrawlBaseDelay;
+// an unchanged one waits twice as long next time, up to RecrawlMaxDelay.
+func (d *Daemon) scheduleRecrawls(ctx context.Context) error {
+	now := time.Now()
+	due, err := GetDueRecrawls(d.RecrawlMinRating, now)
+	if err != nil {
+		return err
+	}
+
+	for _, recrawl := range due {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		delay := d.RecrawlBaseDelay
+		if recrawl.Delay > 0 && recrawl.ModificationTime == recrawl.LastModificationTime {
+			delay = recrawl.Delay * 2
+			if delay > d.RecrawlMaxDelay {
+				delay = d.RecrawlMaxDelay
+			}
+		}
+
+		if err := d.Crawler.Enqueue(recrawl.BlogID, recrawl.Rating); err != nil {
+			return err
+		}
+		if err := SaveRecrawl(recrawl.BlogID, recrawl.ModificationTime, delay, now.Add(delay)); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Enqueued %d blogs for re-crawling...\n", len(due))
+	return nil
+}
+
+// JobState records when a job last started a successful run; a failed run
+// only updates LastError, so LastRun stays a safe watermark.
+type JobState struct {
+	LastRun   time.Time
+	LastError string
+}
+
+func GetJobState(name string) (*JobState, error) {
+	return store.GetJobState(name)
+}
+
+func SaveJobState(name string, lastRun time.Time, jobErr error) error {
+	return store.SaveJobState(name, lastRun, jobErr)
+}
+
+func (s *sqlStore) GetJobState(name string) (*JobState, error) {
+	var lastRun sql.NullInt64
+	var lastError sql.NullString
+	if err := s.db.QueryRow("SELECT last_run, last_error FROM daemon_jobs WHERE name = ?", name).Scan(&lastRun, &lastError); err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	state := &JobState{LastError: lastError.String}
+	if lastRun.Valid {
+		state.LastRun = time.Unix(lastRun.Int64, 0)
+	}
+	return state, nil
+}
+
+func (s *sqlStore) SaveJobState(name string, lastRun time.Time, jobErr error) error {
+	run := sql.NullInt64{Int64: lastRun.Unix(), Valid: true}
+	var lastError sql.NullString
+	if jobErr != nil {
+		run = sql.NullInt64{}
+		lastError = sql.NullString{String: jobErr.Error(), Valid: true}
+	}
+
+	_, err := s.db.Exec("INSERT INTO daemon_jobs (name, last_run, last_error) VALUES (?, ?, ?) ON CONFLICT (name) DO UPDATE SET last_run = COALESCE(excluded.last_run, daemon_jobs.last_run), last_error = excluded.last_error", name, run, lastError)
+	return err
+}
+
+type Recrawl struct {
+	BlogID               int
+	Rating               int
+	ModificationTime     int
+	LastModificationTime int
+	Delay                time.Duration
+}
+
+func GetDueRecrawls(minRating int, now time.Time) ([]*Recrawl, error) {
+	return store.GetDueRecrawls(minRating, now)
+}
+
+func SaveRecrawl(blogID, modificationTime int, delay time.Duration, nextRun time.Time) error {
+	return store.SaveRecrawl(blogID, modificationTime, delay, nextRun)
+}
+
+func (s *sqlStore) GetDueRecrawls(minRating int, now time.Time) ([]*Recrawl, error) {
+	rows, err := s.db.Query(`SELECT blog_entries.id, blog_entries.rating, blog_entries.modification_time, COALESCE(recrawls.last_modification_time, 0), COALESCE(recrawls.delay, 0)
+		FROM blog_entries LEFT JOIN recrawls ON recrawls.blog_id = blog_entries.id
+		WHERE blog_entries.rating >= ? AND COALESCE(recrawls.next_run, 0) <= ?
+		ORDER BY blog_entries.rating DESC`, minRating, now.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recrawls := []*Recrawl{}
+	for rows.Next() {
+		var delay int64
+		recrawl := new(Recrawl)
+		if err := rows.Scan(&recrawl.BlogID, &recrawl.Rating, &recrawl.ModificationTime, &recrawl.LastModificationTime, &delay); err != nil {
+			return nil, err
+		}
+
+		recrawl.Delay = time.Duration(delay) * time.Second
+		recrawls = append(recrawls, recrawl)
+	}
+
+	return recrawls, rows.Err()
+}
+
+func (s *sqlStore) SaveRecrawl(blogID, modificationTime int, delay time.Duration, nextRun time.Time) error {
+	_, err := s.db.Exec("INSERT INTO recrawls (blog_id, last_modification_time, delay, next_run) VALUES (?, ?, ?, ?) ON CONFLICT (blog_id) DO UPDATE SET last_modification_time = excluded.last_modification_time, delay = excluded.delay, next_run = excluded.next_run", blogID, modificationTime, int64(delay/time.Second), nextRun.Unix())
+	return err
+}
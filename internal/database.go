@@ -3,114 +3,362 @@ package internal
 import (
 	"database/sql"
 	"encoding/json"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
-	_ "github.com/mattn/go-sqlite3"
 )
 
-var db *sql.DB = nil
+// store is the database InitDB or OpenDB opened, used by the package-level
+// queries.
+var store Store = nil
+
+// DefaultDatabaseURL is the database InitDB opens unless DATABASE_URL is
+// set.
+const DefaultDatabaseURL = "./db.sqlite3"
+
+// DatabaseURL returns the DSN of the database: a PostgreSQL URL or a SQLite
+// path, as accepted by OpenStore.
+func DatabaseURL() string {
+	if dsn := os.Getenv("DATABASE_URL"); dsn != "" {
+		return dsn
+	}
+	return DefaultDatabaseURL
+}
 
 func InitDB() {
-	if db != nil {
+	if store != nil {
 		return
 	}
 
-	var err error
-	db, err = sql.Open("sqlite3", "./db.sqlite3")
-	if err != nil {
+	if err := OpenDB(DatabaseURL()); err != nil {
 		panic(err)
 	}
+}
 
-	executionCommands := []string{
-		`CREATE TABLE IF NOT EXISTS blog_entries (
-			id INTEGER PRIMARY KEY,
-			original_locale TEXT,
-			creation_time INTEGER,
-			author_handle TEXT,
-			title TEXT,
-			content TEXT,
-			locale TEXT,
-			modification_time INTEGER,
-			allow_view_history BOOLEAN,
-			tags JSON,
-			rating INTEGER NULL,
-			comments JSON
-		)`,
-		`CREATE TABLE IF NOT EXISTS problems (
-			contest_id INTEGER NULL,
-			problemset_name TEXT NULL,
-			idx TEXT,
-			name TEXT,
-			type TEXT,
-			points REAL,
-			rating INTEGER NULL,
-			tags JSON,
-			solved_count INTEGER NULL,
-			PRIMARY KEY (contest_id, idx)
-		)`,
-		`CREATE TABLE IF NOT EXISTS referenced_problems (
-			id INTEGER PRIMARY KEY,
-			blog_id INTEGER,
-			problem_type TEXT,
-			problem_id INTEGER,
-			idx TEXT,
-			tags JSON
-		)`,
-		"CREATE INDEX IF NOT EXISTS idx_blog_entries_title ON blog_entries (title)",
-		"CREATE INDEX IF NOT EXISTS idx_blog_entries_tags ON blog_entries (tags)",
-		"CREATE INDEX IF NOT EXISTS idx_blog_entries_rating ON blog_entries (rating)",
-		"CREATE INDEX IF NOT EXISTS idx_problems_contest_id ON problems (contest_id)",
-		"CREATE INDEX IF NOT EXISTS idx_problems_idx ON problems (idx)",
-		"CREATE INDEX IF NOT EXISTS idx_problems_rating ON problems (rating)",
-		"CREATE INDEX IF NOT EXISTS idx_problems_tags ON problems (tags)",
-		"PRAGMA foreign_keys = ON",
-		"VACUUM",
-		"ANALYZE",
-		"REINDEX",
-	}
-
-	for _, command := range executionCommands {
-		_, err := db.Exec(command)
-		if err != nil {
-			panic(err)
-		}
+// ConnectDB opens the store at dsn without migrating it.
+func ConnectDB(dsn string) error {
+	opened, err := OpenStore(dsn)
+	if err != nil {
+		return err
+	}
+
+	store = opened
+	return nil
+}
+
+// OpenDB opens the store at dsn and applies pending migrations.
+func OpenDB(dsn string) error {
+	if err := ConnectDB(dsn); err != nil {
+		return err
 	}
+
+	_, err := store.Migrate(false)
+	return err
+}
+
+func CloseDB() error {
+	if store == nil {
+		return nil
+	}
+
+	err := store.Close()
+	store = nil
+	return err
 }
 
 func SaveBlogEntry(blog *codeforces.BlogEntry) error {
-	marshaledTags, err := json.Marshal(blog.Tags)
+	return store.SaveBlogEntry(blog)
+}
+
+func GetBlogEntry(blogID int) (*codeforces.BlogEntry, error) {
+	return store.GetBlogEntry(blogID)
+}
+
+func GetBlogIDsByTags(tags []string) ([]int, error) {
+	return store.GetBlogIDsByTags(tags)
+}
+
+func SaveProblem(problem *codeforces.Problem) error {
+	return store.SaveProblem(problem)
+}
+
+func GetContestProblems(contestID int) ([]*codeforces.Problem, error) {
+	return store.GetContestProblems(contestID)
+}
+
+func GetProblemsByTag(tag string, rating int) ([]*codeforces.Problem, error) {
+	return store.GetProblemsByTag(tag, rating)
+}
+
+func SaveReferencedProblem(referenced *codeforces.ReferencedProblem) error {
+	return store.SaveReferencedProblem(referenced)
+}
+
+func GetReferencedProblems(blogID int) ([]*codeforces.ReferencedProblem, error) {
+	return store.GetReferencedProblems(blogID)
+}
+
+func SaveUser(user *codeforces.User) error {
+	return store.SaveUser(user)
+}
+
+func GetCommentsMentioningProblem(handle string, contestID int, index string) ([]codeforces.Comment, error) {
+	return store.GetCommentsMentioningProblem(handle, contestID, index)
+}
+
+func SaveBlogClass(blogID int, class BlogClass, contestID int) error {
+	return store.SaveBlogClass(blogID, class, contestID)
+}
+
+func GetBlogClass(blogID int) (BlogClass, int, error) {
+	return store.GetBlogClass(blogID)
+}
+
+func GetEditorialBlogIDs(contestID int) ([]int, error) {
+	return store.GetEditorialBlogIDs(contestID)
+}
+
+func GetCommentHashes(blogID int) (map[int]CommentState, error) {
+	return store.GetCommentHashes(blogID)
+}
+
+func SaveCommentHash(blogID int, comment *codeforces.Comment, hash string) error {
+	return store.SaveCommentHash(blogID, comment, hash)
+}
+
+func MarkCommentDeleted(blogID, commentID int, deletedAt time.Time) error {
+	return store.MarkCommentDeleted(blogID, commentID, deletedAt)
+}
+
+func GetDeletedCommentIDs(blogID int) ([]int, error) {
+	return store.GetDeletedCommentIDs(blogID)
+}
+
+func MarkCommentReferencesStale(blogID, commentID int) error {
+	return store.MarkCommentReferencesStale(blogID, commentID)
+}
+
+func Migrations() ([]Migration, error) {
+	return store.Migrations()
+}
+
+func SchemaVersion() (int, error) {
+	return store.SchemaVersion()
+}
+
+func Migrate(dryRun bool) ([]Migration, error) {
+	return store.Migrate(dryRun)
+}
+
+// sqlStore implements the queries of Store shared by SQLiteStore and
+// PostgresStore.
+type sqlStore struct {
+	db *sqlDB
+}
+
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+// SaveUser saves what's known about a user; handles seen only as authors of
+// blogs and comments are saved without rating.
+func (s *sqlStore) SaveUser(user *codeforces.User) error {
+	_, err := s.db.Exec("INSERT INTO users (handle, rating, max_rating, rank) VALUES (?, ?, ?, ?) ON CONFLICT (handle) DO UPDATE SET rating = excluded.rating, max_rating = excluded.max_rating, rank = excluded.rank", user.Handle, user.Rating, user.MaxRating, user.Rank)
+	return err
+}
+
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func saveHandle(tx execer, handle string) error {
+	if handle == "" {
+		return nil
+	}
+
+	_, err := tx.Exec("INSERT INTO users (handle) VALUES (?) ON CONFLICT DO NOTHING", handle)
+	return err
+}
+
+// SaveBlogEntry saves a blog with its tags and comments. Comments missing
+// from blog are kept, since they may have been deleted; their text hashes
+// are saved separately by SaveCommentHash.
+func (s *sqlStore) SaveBlogEntry(blog *codeforces.BlogEntry) error {
+	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	if err := saveHandle(tx, blog.AuthorHandle); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO blog_entries (id, original_locale, creation_time, author_handle, title, content, locale, modification_time, allow_view_history, rating) VALUES (?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?) ON CONFLICT (id) DO UPDATE SET original_locale = excluded.original_locale, creation_time = excluded.creation_time, author_handle = excluded.author_handle, title = excluded.title, content = excluded.content, locale = excluded.locale, modification_time = excluded.modification_time, allow_view_history = excluded.allow_view_history, rating = excluded.rating", blog.ID, blog.OriginalLocale, blog.CreationTimeSeconds, blog.AuthorHandle, blog.Title, blog.Content, blog.Locale, blog.ModificationTimeSeconds, blog.AllowViewHistory, blog.Rating); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM blog_tags WHERE blog_id = ?", blog.ID); err != nil {
+		return err
+	}
+	for _, tag := range blog.Tags {
+		if _, err := tx.Exec("INSERT INTO blog_tags (blog_id, tag) VALUES (?, ?) ON CONFLICT DO NOTHING", blog.ID, tag); err != nil {
+			return err
+		}
+	}
+
+	commentIDs := make(map[int]bool)
+	for _, comment := range blog.Comments {
+		commentIDs[comment.ID] = true
+	}
+	for _, comment := range blog.Comments {
+		if err := saveHandle(tx, comment.CommentatorHandle); err != nil {
+			return err
+		}
+
+		// Replies to comments that were never saved are kept as top level
+		// comments.
+		parentID := comment.ParentCommentId
+		if parentID != 0 && !commentIDs[parentID] {
+			if err := tx.QueryRow("SELECT id FROM comments WHERE id = ?", parentID).Scan(&parentID); err == sql.ErrNoRows {
+				parentID = 0
+			} else if err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec("INSERT INTO comments (id, blog_id, parent_comment_id, author, rating, time, locale, text) VALUES (?, ?, NULLIF(?, 0), NULLIF(?, ''), ?, ?, ?, ?) ON CONFLICT (id) DO UPDATE SET parent_comment_id = excluded.parent_comment_id, author = excluded.author, rating = excluded.rating, time = excluded.time, locale = excluded.locale, text = excluded.text", comment.ID, blog.ID, parentID, comment.CommentatorHandle, comment.Rating, comment.CreationTimeSeconds, comment.Locale, comment.Text); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SaveBlogClass records the class of a saved blog and, for editorials, the
+// contest it's about (0 if unknown).
+func (s *sqlStore) SaveBlogClass(blogID int, class BlogClass, contestID int) error {
+	_, err := s.db.Exec("UPDATE blog_entries SET category = ?, contest_id = NULLIF(?, 0) WHERE id = ?", class, contestID, blogID)
+	return err
+}
+
+func (s *sqlStore) GetBlogClass(blogID int) (BlogClass, int, error) {
+	var class sql.NullString
+	var contestID sql.NullInt64
+	if err := s.db.QueryRow("SELECT category, contest_id FROM blog_entries WHERE id = ?", blogID).Scan(&class, &contestID); err != nil {
+		return "", 0, err
+	}
+
+	return BlogClass(class.String), int(contestID.Int64), nil
+}
+
+// GetEditorialBlogIDs returns the editorials of a contest.
+func (s *sqlStore) GetEditorialBlogIDs(contestID int) ([]int, error) {
+	rows, err := s.db.Query("SELECT id FROM blog_entries WHERE category = ? AND contest_id = ? ORDER BY id", BlogEditorial, contestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blogIDs := []int{}
+	for rows.Next() {
+		var blogID int
+		if err := rows.Scan(&blogID); err != nil {
+			return nil, err
+		}
+		blogIDs = append(blogIDs, blogID)
+	}
+
+	return blogIDs, rows.Err()
+}
 
-	marshaledComments, err := json.Marshal(blog.Comments)
+func (s *sqlStore) SaveProblem(problem *codeforces.Problem) error {
+	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	if _, err = db.Exec("INSERT INTO blog_entries (id, original_locale, creation_time, author_handle, title, content, locale, modification_time, allow_view_history, tags, rating, comments) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", blog.ID, blog.OriginalLocale, blog.CreationTimeSeconds, blog.AuthorHandle, blog.Title, blog.Content, blog.Locale, blog.ModificationTimeSeconds, blog.AllowViewHistory, marshaledTags, blog.Rating, marshaledComments); err != nil {
-		_, err = db.Exec("UPDATE blog_entries SET original_locale = ?, creation_time = ?, author_handle = ?, title = ?, content = ?, locale = ?, modification_time = ?, allow_view_history = ?, tags = ?, rating = ?, comments = ? WHERE id = ?", blog.OriginalLocale, blog.CreationTimeSeconds, blog.AuthorHandle, blog.Title, blog.Content, blog.Locale, blog.ModificationTimeSeconds, blog.AllowViewHistory, marshaledTags, blog.Rating, marshaledComments, blog.ID)
-		if err != nil {
+	if _, err := tx.Exec("INSERT INTO problems (contest_id, problemset_name, idx, name, type, points, rating, solved_count) VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (contest_id, idx) DO UPDATE SET problemset_name = excluded.problemset_name, name = excluded.name, type = excluded.type, points = excluded.points, rating = excluded.rating, solved_count = excluded.solved_count", problem.ContestID, problem.ProblemsetName, problem.Index, problem.Name, problem.Type, problem.Points, problem.Rating, problem.SolvedCount); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM problem_tags WHERE contest_id = ? AND idx = ?", problem.ContestID, problem.Index); err != nil {
+		return err
+	}
+	for _, tag := range problem.Tags {
+		if _, err := tx.Exec("INSERT INTO problem_tags (contest_id, idx, tag) VALUES (?, ?, ?) ON CONFLICT DO NOTHING", problem.ContestID, problem.Index, tag); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-func SaveProblem(problem *codeforces.Problem) error {
-	marshaledTags, err := json.Marshal(problem.Tags)
+func (s *sqlStore) getProblemTags(problem *codeforces.Problem) error {
+	rows, err := s.db.Query("SELECT tag FROM problem_tags WHERE contest_id = ? AND idx = ? ORDER BY tag", problem.ContestID, problem.Index)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
-	if _, err = db.Exec("INSERT INTO problems (contest_id, problemset_name, idx, name, type, points, rating, tags, solved_count) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", problem.ContestID, problem.ProblemsetName, problem.Index, problem.Name, problem.Type, problem.Points, problem.Rating, marshaledTags, problem.SolvedCount); err != nil {
-		if _, err = db.Exec("UPDATE problems SET problemset_name = ?, name = ?, type = ?, points = ?, rating = ?, tags = ?, solved_count = ? WHERE contest_id = ? AND idx = ?", problem.ProblemsetName, problem.Name, problem.Type, problem.Points, problem.Rating, marshaledTags, problem.SolvedCount, problem.ContestID, problem.Index); err != nil {
+	problem.Tags = []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
 			return err
 		}
+		problem.Tags = append(problem.Tags, tag)
 	}
 
-	return nil
+	return rows.Err()
+}
+
+func (s *sqlStore) queryProblems(query string, args ...any) ([]*codeforces.Problem, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := []*codeforces.Problem{}
+	for rows.Next() {
+		var rating, solvedCount sql.NullInt64
+		problem := new(codeforces.Problem)
+		if err := rows.Scan(&problem.ContestID, &problem.ProblemsetName, &problem.Index, &problem.Name, &problem.Type, &problem.Points, &rating, &solvedCount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		problem.Rating, problem.SolvedCount = int(rating.Int64), int(solvedCount.Int64)
+
+		problems = append(problems, problem)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The single connection is free again once rows is closed.
+	for _, problem := range problems {
+		if err := s.getProblemTags(problem); err != nil {
+			return nil, err
+		}
+	}
+
+	return problems, nil
+}
+
+func (s *sqlStore) GetContestProblems(contestID int) ([]*codeforces.Problem, error) {
+	return s.queryProblems("SELECT contest_id, COALESCE(problemset_name, ''), idx, name, type, points, rating, solved_count FROM problems WHERE contest_id = ? ORDER BY idx", contestID)
+}
+
+// GetProblemsByTag returns the problems with an official tag, of the given
+// rating unless it's 0.
+func (s *sqlStore) GetProblemsByTag(tag string, rating int) ([]*codeforces.Problem, error) {
+	return s.queryProblems(`SELECT problems.contest_id, COALESCE(problems.problemset_name, ''), problems.idx, problems.name, problems.type, problems.points, problems.rating, problems.solved_count
+		FROM problems JOIN problem_tags ON problem_tags.contest_id = problems.contest_id AND problem_tags.idx = problems.idx
+		WHERE problem_tags.tag = ? AND (? = 0 OR problems.rating = ?)
+		ORDER BY problems.contest_id, problems.idx`, tag, rating, rating)
 }
 
 func mergeTags(currentTags []string, newTags []string) []string {
@@ -131,16 +379,16 @@ func mergeTags(currentTags []string, newTags []string) []string {
 	return currentTags
 }
 
-func SaveReferencedProblem(referenced *codeforces.ReferencedProblem) error {
+func (s *sqlStore) SaveReferencedProblem(referenced *codeforces.ReferencedProblem) error {
 	var referencedID int
 	var currentMarshaledTags []byte
-	if err := db.QueryRow("SELECT id, tags FROM referenced_problems WHERE blog_id = ? AND problem_type = ? AND problem_id = ? AND idx = ?", referenced.BlogID, referenced.ProblemType, referenced.ProblemID, referenced.Index).Scan(&referencedID, &currentMarshaledTags); err == sql.ErrNoRows {
+	if err := s.db.QueryRow("SELECT id, tags FROM referenced_problems WHERE blog_id = ? AND problem_type = ? AND problem_id = ? AND idx = ? AND comment_id = ?", referenced.BlogID, referenced.ProblemType, referenced.ProblemID, referenced.Index, referenced.CommentID).Scan(&referencedID, &currentMarshaledTags); err == sql.ErrNoRows {
 		marshaledTags, err := json.Marshal(referenced.Tags)
 		if err != nil {
 			return err
 		}
 
-		if _, err = db.Exec("INSERT INTO referenced_problems (blog_id, problem_type, problem_id, idx, tags) VALUES (?, ?, ?, ?, ?)", referenced.BlogID, referenced.ProblemType, referenced.ProblemID, referenced.Index, marshaledTags); err != nil {
+		if _, err = s.db.Exec("INSERT INTO referenced_problems (blog_id, problem_type, problem_id, idx, tags, snippet, comment_id, comment_author) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", referenced.BlogID, referenced.ProblemType, referenced.ProblemID, referenced.Index, string(marshaledTags), referenced.Snippet, referenced.CommentID, referenced.CommentAuthor); err != nil {
 			return err
 		}
 	} else if err != nil {
@@ -158,7 +406,7 @@ func SaveReferencedProblem(referenced *codeforces.ReferencedProblem) error {
 			return err
 		}
 
-		if _, err = db.Exec("UPDATE referenced_problems SET tags = ? WHERE id = ?", marshaledTags, referencedID); err != nil {
+		if _, err = s.db.Exec("UPDATE referenced_problems SET tags = ?, snippet = ?, comment_author = ?, stale = FALSE WHERE id = ?", string(marshaledTags), referenced.Snippet, referenced.CommentAuthor, referencedID); err != nil {
 			return err
 		}
 	}
@@ -166,21 +414,188 @@ func SaveReferencedProblem(referenced *codeforces.ReferencedProblem) error {
 	return nil
 }
 
-func GetBlogEntry(blogID int) (*codeforces.BlogEntry, error) {
-	var marshaledTags []byte
-	var marshaledComments []byte
-	blog := new(codeforces.BlogEntry)
-	if err := db.QueryRow("SELECT original_locale, creation_time, author_handle, title, content, locale, modification_time, allow_view_history, tags, rating, comments FROM blog_entries WHERE id = ?", blogID).Scan(&blog.OriginalLocale, &blog.CreationTimeSeconds, &blog.AuthorHandle, &blog.Title, &blog.Content, &blog.Locale, &blog.ModificationTimeSeconds, &blog.AllowViewHistory, &marshaledTags, &blog.Rating, &marshaledComments); err != nil {
+type CommentState struct {
+	Hash    string
+	Deleted bool
+}
+
+func (s *sqlStore) GetCommentHashes(blogID int) (map[int]CommentState, error) {
+	rows, err := s.db.Query("SELECT id, COALESCE(text_hash, ''), deleted_at IS NOT NULL FROM comments WHERE blog_id = ?", blogID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make(map[int]CommentState)
+	for rows.Next() {
+		var commentID int
+		var state CommentState
+		if err := rows.Scan(&commentID, &state.Hash, &state.Deleted); err != nil {
+			return nil, err
+		}
+		states[commentID] = state
+	}
+
+	return states, rows.Err()
+}
+
+// SaveCommentHash records the hash of a comment saved by SaveBlogEntry.
+func (s *sqlStore) SaveCommentHash(blogID int, comment *codeforces.Comment, hash string) error {
+	_, err := s.db.Exec("UPDATE comments SET text_hash = ?, deleted_at = NULL WHERE blog_id = ? AND id = ?", hash, blogID, comment.ID)
+	return err
+}
+
+func (s *sqlStore) MarkCommentDeleted(blogID, commentID int, deletedAt time.Time) error {
+	_, err := s.db.Exec("UPDATE comments SET deleted_at = ? WHERE blog_id = ? AND id = ?", deletedAt.Unix(), blogID, commentID)
+	return err
+}
+
+// GetDeletedCommentIDs returns the comments of a blog that were deleted
+// after it was crawled.
+func (s *sqlStore) GetDeletedCommentIDs(blogID int) ([]int, error) {
+	rows, err := s.db.Query("SELECT id FROM comments WHERE blog_id = ? AND deleted_at IS NOT NULL ORDER BY id", blogID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	commentIDs := []int{}
+	for rows.Next() {
+		var commentID int
+		if err := rows.Scan(&commentID); err != nil {
+			return nil, err
+		}
+		commentIDs = append(commentIDs, commentID)
+	}
+
+	return commentIDs, rows.Err()
+}
+
+func (s *sqlStore) MarkCommentReferencesStale(blogID, commentID int) error {
+	_, err := s.db.Exec("UPDATE referenced_problems SET stale = TRUE WHERE blog_id = ? AND comment_id = ?", blogID, commentID)
+	return err
+}
+
+func scanComments(rows *sql.Rows) ([]codeforces.Comment, error) {
+	defer rows.Close()
+
+	comments := []codeforces.Comment{}
+	for rows.Next() {
+		var comment codeforces.Comment
+		var parentID, rating, creationTime sql.NullInt64
+		var locale, text sql.NullString
+		if err := rows.Scan(&comment.ID, &parentID, &comment.CommentatorHandle, &rating, &creationTime, &locale, &text); err != nil {
+			return nil, err
+		}
+		comment.ParentCommentId, comment.Rating, comment.CreationTimeSeconds = int(parentID.Int64), int(rating.Int64), int(creationTime.Int64)
+		comment.Locale, comment.Text = locale.String, text.String
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+func (s *sqlStore) GetBlogEntry(blogID int) (*codeforces.BlogEntry, error) {
+	blog := &codeforces.BlogEntry{ID: blogID}
+	if err := s.db.QueryRow("SELECT original_locale, creation_time, COALESCE(author_handle, ''), title, content, locale, modification_time, allow_view_history, COALESCE(rating, 0) FROM blog_entries WHERE id = ?", blogID).Scan(&blog.OriginalLocale, &blog.CreationTimeSeconds, &blog.AuthorHandle, &blog.Title, &blog.Content, &blog.Locale, &blog.ModificationTimeSeconds, &blog.AllowViewHistory, &blog.Rating); err != nil {
 		return nil, err
 	}
 
-	if err := json.Unmarshal(marshaledTags, &blog.Tags); err != nil {
+	rows, err := s.db.Query("SELECT tag FROM blog_tags WHERE blog_id = ? ORDER BY tag", blogID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blog.Tags = []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		blog.Tags = append(blog.Tags, tag)
+	}
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	rows.Close()
 
-	if err := json.Unmarshal(marshaledComments, &blog.Comments); err != nil {
+	commentRows, err := s.db.Query("SELECT id, parent_comment_id, COALESCE(author, ''), rating, time, locale, text FROM comments WHERE blog_id = ? AND deleted_at IS NULL ORDER BY time, id", blogID)
+	if err != nil {
+		return nil, err
+	}
+	if blog.Comments, err = scanComments(commentRows); err != nil {
 		return nil, err
 	}
 
 	return blog, nil
 }
+
+// GetCommentsMentioningProblem returns the comments of handle that mention
+// a problem, by link to it in a contest or the problemset.
+func (s *sqlStore) GetCommentsMentioningProblem(handle string, contestID int, index string) ([]codeforces.Comment, error) {
+	rows, err := s.db.Query(`SELECT DISTINCT comments.id, comments.parent_comment_id, COALESCE(comments.author, ''), comments.rating, comments.time, comments.locale, comments.text
+		FROM comments JOIN referenced_problems ON referenced_problems.comment_id = comments.id
+		WHERE comments.author = ? AND comments.deleted_at IS NULL AND NOT COALESCE(referenced_problems.stale, FALSE)
+			AND referenced_problems.problem_type IN ('contest', 'problemset') AND referenced_problems.problem_id = ? AND referenced_problems.idx = ?
+		ORDER BY comments.time, comments.id`, handle, contestID, index)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanComments(rows)
+}
+
+func (s *sqlStore) GetReferencedProblems(blogID int) ([]*codeforces.ReferencedProblem, error) {
+	rows, err := s.db.Query("SELECT blog_id, problem_type, problem_id, idx, tags, COALESCE(snippet, ''), COALESCE(comment_id, 0), COALESCE(comment_author, ''), COALESCE(stale, FALSE) FROM referenced_problems WHERE blog_id = ? ORDER BY id", blogID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	referencedProblems := []*codeforces.ReferencedProblem{}
+	for rows.Next() {
+		var marshaledTags []byte
+		referenced := new(codeforces.ReferencedProblem)
+		if err := rows.Scan(&referenced.BlogID, &referenced.ProblemType, &referenced.ProblemID, &referenced.Index, &marshaledTags, &referenced.Snippet, &referenced.CommentID, &referenced.CommentAuthor, &referenced.Stale); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(marshaledTags, &referenced.Tags); err != nil {
+			return nil, err
+		}
+
+		referencedProblems = append(referencedProblems, referenced)
+	}
+
+	return referencedProblems, rows.Err()
+}
+
+func (s *sqlStore) GetBlogIDsByTags(tags []string) ([]int, error) {
+	if len(tags) == 0 {
+		return []int{}, nil
+	}
+
+	args := make([]any, len(tags))
+	for i, tag := range tags {
+		args[i] = tag
+	}
+
+	rows, err := s.db.Query("SELECT DISTINCT blog_id FROM blog_tags WHERE tag IN (?"+strings.Repeat(", ?", len(tags)-1)+") ORDER BY blog_id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blogIDs := []int{}
+	for rows.Next() {
+		var blogID int
+		if err := rows.Scan(&blogID); err != nil {
+			return nil, err
+		}
+		blogIDs = append(blogIDs, blogID)
+	}
+
+	return blogIDs, rows.Err()
+}
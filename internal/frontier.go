@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	FrontierPending    = "pending"
+	FrontierInProgress = "in_progress"
+	FrontierDone       = "done"
+	FrontierSkipped    = "skipped"
+	FrontierFailed     = "failed"
+)
+
+type FrontierEntry struct {
+	BlogID   int
+	Depth    int
+	Priority int
+	Status   string
+	Error    string
+	// Attempts counts the crawls that failed with a transient error.
+	Attempts int
+}
+
+func EnqueueBlog(blogID, depth, priority int, force bool) error {
+	return store.EnqueueBlog(blogID, depth, priority, force)
+}
+
+func NextFrontierEntry() (*FrontierEntry, error) {
+	return store.NextFrontierEntry()
+}
+
+func FinishFrontierEntry(blogID int, status string, crawlErr error) error {
+	return store.FinishFrontierEntry(blogID, status, crawlErr)
+}
+
+func RetryFrontierEntry(blogID int, retryAt time.Time, crawlErr error) error {
+	return store.RetryFrontierEntry(blogID, retryAt, crawlErr)
+}
+
+func NextFrontierRetry() (time.Time, error) {
+	return store.NextFrontierRetry()
+}
+
+func ResetInterruptedFrontier(claimedBefore time.Time) error {
+	return store.ResetInterruptedFrontier(claimedBefore)
+}
+
+func GetFrontierEntry(blogID int) (*FrontierEntry, error) {
+	return store.GetFrontierEntry(blogID)
+}
+
+func CountFrontier(status string) (int, error) {
+	return store.CountFrontier(status)
+}
+
+// EnqueueBlog adds a blog to the crawl frontier. Blogs that were already
+// visited are left alone unless force is set; pending blogs keep the
+// shallowest depth and the highest priority they were enqueued with.
+func (s *sqlStore) EnqueueBlog(blogID, depth, priority int, force bool) error {
+	now := time.Now().Unix()
+	if force {
+		_, err := s.db.Exec(`INSERT INTO crawl_frontier (blog_id, depth, priority, status, enqueued_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (blog_id) DO UPDATE SET depth = excluded.depth, priority = excluded.priority, status = excluded.status, error = NULL, attempts = 0, retry_at = NULL, enqueued_at = excluded.enqueued_at, updated_at = excluded.updated_at`,
+			blogID, depth, priority, FrontierPending, now, now)
+		return err
+	}
+
+	_, err := s.db.Exec(`INSERT INTO crawl_frontier (blog_id, depth, priority, status, enqueued_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (blog_id) DO UPDATE SET
+			depth = CASE WHEN excluded.depth < crawl_frontier.depth THEN excluded.depth ELSE crawl_frontier.depth END,
+			priority = CASE WHEN excluded.priority > crawl_frontier.priority THEN excluded.priority ELSE crawl_frontier.priority END,
+			updated_at = excluded.updated_at
+		WHERE crawl_frontier.status = ?`,
+		blogID, depth, priority, FrontierPending, now, now, FrontierPending)
+	return err
+}
+
+// NextFrontierEntry claims the pending blog with the highest priority,
+// preferring shallower blogs on ties. Blogs waiting to be retried are
+// skipped until their retry time. It returns sql.ErrNoRows when no blog can
+// be claimed.
+func (s *sqlStore) NextFrontierEntry() (*FrontierEntry, error) {
+	entry := new(FrontierEntry)
+	if err := s.db.QueryRow("SELECT blog_id, depth, priority, COALESCE(attempts, 0) FROM crawl_frontier WHERE status = ? AND COALESCE(retry_at, 0) <= ? ORDER BY priority DESC, depth, enqueued_at, blog_id LIMIT 1", FrontierPending, time.Now().Unix()).Scan(&entry.BlogID, &entry.Depth, &entry.Priority, &entry.Attempts); err != nil {
+		return nil, err
+	}
+
+	result, err := s.db.Exec("UPDATE crawl_frontier SET status = ?, updated_at = ? WHERE blog_id = ? AND status = ?", FrontierInProgress, time.Now().Unix(), entry.BlogID, FrontierPending)
+	if err != nil {
+		return nil, err
+	}
+	if claimed, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if claimed == 0 {
+		return s.NextFrontierEntry()
+	}
+
+	entry.Status = FrontierInProgress
+	return entry, nil
+}
+
+func (s *sqlStore) FinishFrontierEntry(blogID int, status string, crawlErr error) error {
+	var message sql.NullString
+	if crawlErr != nil {
+		message = sql.NullString{String: crawlErr.Error(), Valid: true}
+	}
+
+	_, err := s.db.Exec("UPDATE crawl_frontier SET status = ?, error = ?, updated_at = ? WHERE blog_id = ?", status, message, time.Now().Unix(), blogID)
+	return err
+}
+
+// RetryFrontierEntry puts a blog whose crawl failed with a transient error
+// back into the pending state, to be claimed again no earlier than retryAt.
+func (s *sqlStore) RetryFrontierEntry(blogID int, retryAt time.Time, crawlErr error) error {
+	_, err := s.db.Exec("UPDATE crawl_frontier SET status = ?, error = ?, attempts = COALESCE(attempts, 0) + 1, retry_at = ?, updated_at = ? WHERE blog_id = ?", FrontierPending, crawlErr.Error(), retryAt.Unix(), time.Now().Unix(), blogID)
+	return err
+}
+
+// NextFrontierRetry returns the earliest time a pending blog waiting to be
+// retried can be claimed, or sql.ErrNoRows if none is waiting.
+func (s *sqlStore) NextFrontierRetry() (time.Time, error) {
+	var retryAt sql.NullInt64
+	if err := s.db.QueryRow("SELECT MIN(retry_at) FROM crawl_frontier WHERE status = ?", FrontierPending).Scan(&retryAt); err != nil {
+		return time.Time{}, err
+	}
+	if !retryAt.Valid {
+		return time.Time{}, sql.ErrNoRows
+	}
+
+	return time.Unix(retryAt.Int64, 0), nil
+}
+
+// ResetInterruptedFrontier puts blogs that were claimed at or before
+// claimedBefore and are still in flight back into the pending state. Newer
+// claims may belong to another crawler sharing the database.
+func (s *sqlStore) ResetInterruptedFrontier(claimedBefore time.Time) error {
+	_, err := s.db.Exec("UPDATE crawl_frontier SET status = ? WHERE status = ? AND updated_at <= ?", FrontierPending, FrontierInProgress, claimedBefore.Unix())
+	return err
+}
+
+func (s *sqlStore) GetFrontierEntry(blogID int) (*FrontierEntry, error) {
+	var message sql.NullString
+	entry := &FrontierEntry{BlogID: blogID}
+	if err := s.db.QueryRow("SELECT depth, priority, status, error, COALESCE(attempts, 0) FROM crawl_frontier WHERE blog_id = ?", blogID).Scan(&entry.Depth, &entry.Priority, &entry.Status, &message, &entry.Attempts); err != nil {
+		return nil, err
+	}
+
+	entry.Error = message.String
+	return entry, nil
+}
+
+func (s *sqlStore) CountFrontier(status string) (int, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM crawl_frontier WHERE status = ?", status).Scan(&count)
+	return count, err
+}
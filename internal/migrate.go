@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration is an up migration, embedded from
+// migrations/<backend>/<version>_<name>.sql. Both backends have the same
+// versions.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// loadMigrations returns the migrations of a backend ordered by version.
+func loadMigrations(backend string) ([]Migration, error) {
+	dir := path.Join("migrations", backend)
+	entries, err := migrationFiles.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []Migration{}
+	versions := make(map[int]string)
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		number, name, ok := strings.Cut(name, "_")
+		version, err := strconv.Atoi(number)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		if other, ok := versions[version]; ok {
+			return nil, fmt.Errorf("migrations %q and %q have the same version", other, entry.Name())
+		}
+		versions[version] = entry.Name()
+
+		content, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(content)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func pendingMigrations(migrations []Migration, version int) []Migration {
+	pending := []Migration{}
+	for _, migration := range migrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending
+}
+
+const schemaVersionTable = `CREATE TABLE IF NOT EXISTS schema_version (
+	version INTEGER PRIMARY KEY,
+	name TEXT,
+	applied_at BIGINT
+)`
+
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// schemaVersion returns the version of the last applied migration, 0 for a
+// new database or one that predates migrations. tableExists is the
+// backend's query for whether a table exists.
+func schemaVersion(q queryRower, tableExists string) (int, error) {
+	var exists bool
+	if err := q.QueryRow(tableExists, "schema_version").Scan(&exists); err != nil || !exists {
+		return 0, err
+	}
+
+	var version int
+	err := q.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	return version, err
+}
+
+func recordMigration(tx *sqlTx, migration Migration) error {
+	_, err := tx.Exec("INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)", migration.Version, migration.Name, time.Now().Unix())
+	return err
+}
@@ -0,0 +1,115 @@
+-- The schema as of the introduction of migrations. Tables and indexes are
+-- created only if missing, since databases that predate migrations already
+-- have some of them.
+
+CREATE TABLE IF NOT EXISTS users (
+	handle TEXT NOT NULL PRIMARY KEY,
+	rating INTEGER NULL,
+	max_rating INTEGER NULL,
+	rank TEXT NULL
+);
+
+CREATE TABLE IF NOT EXISTS blog_entries (
+	id INTEGER PRIMARY KEY,
+	original_locale TEXT,
+	creation_time INTEGER,
+	author_handle TEXT NULL REFERENCES users (handle),
+	title TEXT,
+	content TEXT,
+	locale TEXT,
+	modification_time INTEGER,
+	allow_view_history BOOLEAN,
+	rating INTEGER NULL,
+	category TEXT NULL,
+	contest_id INTEGER NULL
+);
+
+CREATE TABLE IF NOT EXISTS problems (
+	contest_id INTEGER NOT NULL,
+	problemset_name TEXT NULL,
+	idx TEXT NOT NULL,
+	name TEXT,
+	type TEXT,
+	points REAL,
+	rating INTEGER NULL,
+	solved_count INTEGER NULL,
+	PRIMARY KEY (contest_id, idx)
+);
+
+CREATE TABLE IF NOT EXISTS blog_tags (
+	blog_id INTEGER NOT NULL REFERENCES blog_entries (id),
+	tag TEXT NOT NULL,
+	PRIMARY KEY (blog_id, tag)
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+	id INTEGER PRIMARY KEY,
+	blog_id INTEGER NOT NULL REFERENCES blog_entries (id),
+	parent_comment_id INTEGER NULL REFERENCES comments (id) DEFERRABLE INITIALLY DEFERRED,
+	author TEXT REFERENCES users (handle),
+	rating INTEGER,
+	time INTEGER,
+	locale TEXT,
+	text TEXT,
+	text_hash TEXT NULL,
+	deleted_at INTEGER NULL
+);
+
+CREATE TABLE IF NOT EXISTS problem_tags (
+	contest_id INTEGER NOT NULL,
+	idx TEXT NOT NULL,
+	tag TEXT NOT NULL,
+	PRIMARY KEY (contest_id, idx, tag),
+	FOREIGN KEY (contest_id, idx) REFERENCES problems (contest_id, idx)
+);
+
+CREATE TABLE IF NOT EXISTS referenced_problems (
+	id INTEGER PRIMARY KEY,
+	blog_id INTEGER,
+	problem_type TEXT,
+	problem_id INTEGER,
+	idx TEXT,
+	tags JSON,
+	snippet TEXT,
+	comment_id INTEGER DEFAULT 0,
+	comment_author TEXT NULL,
+	stale BOOLEAN DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS crawl_frontier (
+	blog_id INTEGER PRIMARY KEY,
+	depth INTEGER,
+	priority INTEGER,
+	status TEXT,
+	error TEXT NULL,
+	attempts INTEGER DEFAULT 0,
+	retry_at INTEGER NULL,
+	enqueued_at INTEGER,
+	updated_at INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS daemon_jobs (
+	name TEXT NOT NULL PRIMARY KEY,
+	last_run INTEGER,
+	last_error TEXT NULL
+);
+
+CREATE TABLE IF NOT EXISTS recrawls (
+	blog_id INTEGER PRIMARY KEY,
+	last_modification_time INTEGER,
+	delay INTEGER,
+	next_run INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_blog_entries_title ON blog_entries (title);
+CREATE INDEX IF NOT EXISTS idx_blog_entries_author_handle ON blog_entries (author_handle);
+CREATE INDEX IF NOT EXISTS idx_blog_entries_rating ON blog_entries (rating);
+CREATE INDEX IF NOT EXISTS idx_problems_contest_id ON problems (contest_id);
+CREATE INDEX IF NOT EXISTS idx_problems_idx ON problems (idx);
+CREATE INDEX IF NOT EXISTS idx_problems_rating ON problems (rating);
+CREATE INDEX IF NOT EXISTS idx_blog_tags_tag ON blog_tags (tag);
+CREATE INDEX IF NOT EXISTS idx_problem_tags_tag ON problem_tags (tag);
+CREATE INDEX IF NOT EXISTS idx_comments_blog_id ON comments (blog_id);
+CREATE INDEX IF NOT EXISTS idx_comments_author ON comments (author);
+CREATE INDEX IF NOT EXISTS idx_referenced_problems_problem ON referenced_problems (problem_id, idx);
+CREATE INDEX IF NOT EXISTS idx_crawl_frontier_status ON crawl_frontier (status, priority, depth);
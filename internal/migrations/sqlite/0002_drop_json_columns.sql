@@ -0,0 +1,55 @@
+-- Rebuild blog_entries and problems without the JSON columns, and their
+-- indexes, that databases created before the normalized tables still have,
+-- so every database has the same columns and foreign keys.
+
+DROP INDEX IF EXISTS idx_blog_entries_tags;
+DROP INDEX IF EXISTS idx_problems_tags;
+
+CREATE TABLE blog_entries_new (
+	id INTEGER PRIMARY KEY,
+	original_locale TEXT,
+	creation_time INTEGER,
+	author_handle TEXT NULL REFERENCES users (handle),
+	title TEXT,
+	content TEXT,
+	locale TEXT,
+	modification_time INTEGER,
+	allow_view_history BOOLEAN,
+	rating INTEGER NULL,
+	category TEXT NULL,
+	contest_id INTEGER NULL
+);
+
+INSERT INTO blog_entries_new (id, original_locale, creation_time, author_handle, title, content, locale, modification_time, allow_view_history, rating, category, contest_id)
+	SELECT id, original_locale, creation_time, NULLIF(author_handle, ''), title, content, locale, modification_time, allow_view_history, rating, category, contest_id
+	FROM blog_entries;
+
+DROP TABLE blog_entries;
+ALTER TABLE blog_entries_new RENAME TO blog_entries;
+
+CREATE INDEX idx_blog_entries_title ON blog_entries (title);
+CREATE INDEX idx_blog_entries_author_handle ON blog_entries (author_handle);
+CREATE INDEX idx_blog_entries_rating ON blog_entries (rating);
+
+CREATE TABLE problems_new (
+	contest_id INTEGER NOT NULL,
+	problemset_name TEXT NULL,
+	idx TEXT NOT NULL,
+	name TEXT,
+	type TEXT,
+	points REAL,
+	rating INTEGER NULL,
+	solved_count INTEGER NULL,
+	PRIMARY KEY (contest_id, idx)
+);
+
+INSERT INTO problems_new (contest_id, problemset_name, idx, name, type, points, rating, solved_count)
+	SELECT contest_id, problemset_name, idx, name, type, points, rating, solved_count
+	FROM problems;
+
+DROP TABLE problems;
+ALTER TABLE problems_new RENAME TO problems;
+
+CREATE INDEX idx_problems_contest_id ON problems (contest_id);
+CREATE INDEX idx_problems_idx ON problems (idx);
+CREATE INDEX idx_problems_rating ON problems (rating);
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/lib/pq"
+)
+
+// PostgresStore is a Store in a PostgreSQL database, which several
+// analyzers can share.
+type PostgresStore struct {
+	*sqlStore
+}
+
+func OpenPostgresStore(dsn string) (*PostgresStore, error) {
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &PostgresStore{&sqlStore{db: &sqlDB{DB: conn, postgres: true}}}, nil
+}
+
+const postgresTableExists = "SELECT COUNT(*) > 0 FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?"
+
+func (s *PostgresStore) Migrations() ([]Migration, error) {
+	return loadMigrations("postgres")
+}
+
+func (s *PostgresStore) SchemaVersion() (int, error) {
+	return schemaVersion(s.db, postgresTableExists)
+}
+
+// Migrate applies the pending migrations in order, each in its own
+// transaction, and returns them. With dryRun it only returns them.
+func (s *PostgresStore) Migrate(dryRun bool) ([]Migration, error) {
+	migrations, err := s.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := pendingMigrations(migrations, version)
+	if dryRun || len(pending) == 0 {
+		return pending, nil
+	}
+
+	if _, err := s.db.Exec(schemaVersionTable); err != nil {
+		return nil, err
+	}
+	for _, migration := range pending {
+		if err := s.applyMigration(migration); err != nil {
+			return nil, fmt.Errorf("migration %s: %w", migration, err)
+		}
+	}
+
+	if _, err := s.db.Exec("ANALYZE"); err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+func (s *PostgresStore) applyMigration(migration Migration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Concurrent analyzers sharing the database wait for each other here;
+	// the one that gets the lock second finds the migration applied.
+	if _, err := tx.Exec("LOCK TABLE schema_version IN EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+	var applied bool
+	if err := tx.QueryRow("SELECT COUNT(*) > 0 FROM schema_version WHERE version = ?", migration.Version).Scan(&applied); err != nil || applied {
+		return err
+	}
+
+	if _, err := tx.Exec(migration.SQL); err != nil {
+		return err
+	}
+	if err := recordMigration(tx, migration); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
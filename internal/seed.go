@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+)
+
+// Seeder enqueues starting points for a crawl and reports how many blogs it
+// added to the frontier.
+type Seeder interface {
+	Seed(ctx context.Context, crawler *Crawler) (int, error)
+}
+
+// RecentActionsSeeder enqueues blogs that were recently created, edited or
+// commented on, as reported by recentActions. Actions before Since (a Unix
+// timestamp) are ignored.
+type RecentActionsSeeder struct {
+	MaxCount int
+	Since    int64
+}
+
+func (s RecentActionsSeeder) Seed(ctx context.Context, crawler *Crawler) (int, error) {
+	actions, err := codeforces.GetRecentActionsContext(ctx, s.MaxCount)
+	if err != nil {
+		return 0, err
+	}
+
+	seen := make(map[int]bool)
+	for _, action := range actions {
+		blogID := action.BlogEntry.ID
+		if blogID == 0 || seen[blogID] || int64(action.TimeSeconds) < s.Since {
+			continue
+		}
+		seen[blogID] = true
+
+		if err := crawler.Enqueue(blogID, action.BlogEntry.Rating); err != nil {
+			return len(seen), err
+		}
+	}
+
+	return len(seen), nil
+}
+
+// AuthorsSeeder enqueues the blogs of the given authors that are new or were
+// modified since they were last crawled.
+type AuthorsSeeder struct {
+	Handles []string
+}
+
+func (s AuthorsSeeder) Seed(ctx context.Context, crawler *Crawler) (int, error) {
+	seeded := 0
+	for _, handle := range s.Handles {
+		user := codeforces.User{Handle: handle}
+		blogEntries, err := user.GetBlogEntriesContext(ctx)
+		if err != nil {
+			return seeded, err
+		}
+
+		for _, blogEntry := range blogEntries {
+			lastVersion, err := GetBlogEntry(blogEntry.ID)
+			if err != nil && err != sql.ErrNoRows {
+				return seeded, err
+			}
+			if lastVersion != nil && lastVersion.ModificationTimeSeconds >= blogEntry.ModificationTimeSeconds {
+				continue
+			}
+			if lastVersion == nil {
+				entry, err := GetFrontierEntry(blogEntry.ID)
+				if err != nil && err != sql.ErrNoRows {
+					return seeded, err
+				}
+				if entry != nil && entry.Status == FrontierSkipped {
+					continue
+				}
+			}
+
+			if err := crawler.Enqueue(blogEntry.ID, blogEntry.Rating); err != nil {
+				return seeded, err
+			}
+			seeded++
+		}
+	}
+
+	return seeded, nil
+}
+
+// RangeSeeder enqueues every blog ID in [From, To] that hasn't been visited
+// yet. IDs of deleted blogs are skipped when they are crawled.
+type RangeSeeder struct {
+	From int
+	To   int
+}
+
+func (s RangeSeeder) Seed(ctx context.Context, crawler *Crawler) (int, error) {
+	seeded := 0
+	for blogID := s.From; blogID <= s.To; blogID++ {
+		if err := ctx.Err(); err != nil {
+			return seeded, err
+		}
+		if err := EnqueueBlog(blogID, 0, 0, false); err != nil {
+			return seeded, err
+		}
+		seeded++
+	}
+
+	return seeded, nil
+}
+
+// TagsSeeder re-enqueues already crawled blogs carrying any of the tags.
+type TagsSeeder struct {
+	Tags []string
+}
+
+func (s TagsSeeder) Seed(ctx context.Context, crawler *Crawler) (int, error) {
+	blogIDs, err := GetBlogIDsByTags(s.Tags)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, blogID := range blogIDs {
+		if err := crawler.Enqueue(blogID, 0); err != nil {
+			return i, err
+		}
+	}
+
+	return len(blogIDs), nil
+}
+
+// SeedConfig selects the seeding strategies of a single crawl run. Zero
+// values disable the corresponding strategy.
+type SeedConfig struct {
+	RecentActions int
+	Authors       []string
+	RangeFrom     int
+	RangeTo       int
+	Tags          []string
+}
+
+func (config SeedConfig) Seeders() []Seeder {
+	seeders := []Seeder{}
+	if config.RecentActions > 0 {
+		seeders = append(seeders, RecentActionsSeeder{MaxCount: config.RecentActions})
+	}
+	if len(config.Authors) > 0 {
+		seeders = append(seeders, AuthorsSeeder{Handles: config.Authors})
+	}
+	if config.RangeFrom > 0 && config.RangeTo >= config.RangeFrom {
+		seeders = append(seeders, RangeSeeder{From: config.RangeFrom, To: config.RangeTo})
+	}
+	if len(config.Tags) > 0 {
+		seeders = append(seeders, TagsSeeder{Tags: config.Tags})
+	}
+
+	return seeders
+}
+
+// Seed runs every seeder in order and returns the total number of blogs
+// enqueued. A failing seeder is logged and doesn't stop the others.
+func (c *Crawler) Seed(ctx context.Context, seeders ...Seeder) int {
+	total := 0
+	for _, seeder := range seeders {
+		seeded, err := seeder.Seed(ctx, c)
+		if err != nil {
+			log.Printf("Error seeding crawl with %T: %s\n", seeder, err)
+		}
+		total += seeded
+	}
+
+	return total
+}
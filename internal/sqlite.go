@@ -0,0 +1,189 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteStore is a Store in a SQLite database file.
+type SQLiteStore struct {
+	*sqlStore
+}
+
+// OpenSQLiteStore opens the database at path, a file path or a file: URI
+// that may carry its own query parameters.
+func OpenSQLiteStore(path string) (*SQLiteStore, error) {
+	dsn := path
+	if !strings.HasPrefix(dsn, "file:") {
+		dsn = "file:" + dsn
+	}
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+
+	conn, err := sql.Open("sqlite3", dsn+separator+"_foreign_keys=on")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; funnel crawler workers through one connection.
+	conn.SetMaxOpenConns(1)
+
+	return &SQLiteStore{&sqlStore{db: &sqlDB{DB: conn}}}, nil
+}
+
+const sqliteTableExists = "SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?"
+
+func (s *SQLiteStore) Migrations() ([]Migration, error) {
+	return loadMigrations("sqlite")
+}
+
+func (s *SQLiteStore) SchemaVersion() (int, error) {
+	return schemaVersion(s.db, sqliteTableExists)
+}
+
+// Migrate applies the pending migrations in order, each in its own
+// transaction, and returns them. With dryRun it only returns them.
+//
+// A database created by InitDB before migrations is brought to the
+// baseline schema by the first migration.
+func (s *SQLiteStore) Migrate(dryRun bool) ([]Migration, error) {
+	migrations, err := s.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	var tables int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'").Scan(&tables); err != nil {
+		return nil, err
+	}
+	legacy := version == 0 && tables > 0
+
+	pending := pendingMigrations(migrations, version)
+	if dryRun || len(pending) == 0 {
+		return pending, nil
+	}
+
+	// Foreign keys can only be turned off outside a transaction, so pin the
+	// connection the migrations run on.
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, schemaVersionTable); err != nil {
+		return nil, err
+	}
+
+	// Rebuilding a table drops the one it replaces, which foreign keys
+	// would forbid; they are checked once the migration is done instead.
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return nil, err
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	for _, migration := range pending {
+		if err := applySQLiteMigration(ctx, conn, migration, legacy && migration.Version == 1); err != nil {
+			return nil, fmt.Errorf("migration %s: %w", migration, err)
+		}
+	}
+
+	if _, err := conn.ExecContext(ctx, "ANALYZE"); err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+func applySQLiteMigration(ctx context.Context, conn *sql.Conn, migration Migration, legacy bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(migration.SQL); err != nil {
+		return err
+	}
+	if legacy {
+		if err := upgradeLegacySchema(tx); err != nil {
+			return err
+		}
+	}
+
+	rows, err := tx.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return err
+	}
+	if rows.Next() {
+		var table string
+		var rowID sql.NullInt64
+		var parent string
+		var key int
+		if err := rows.Scan(&table, &rowID, &parent, &key); err != nil {
+			rows.Close()
+			return err
+		}
+		rows.Close()
+		return fmt.Errorf("row %d of %s references a missing row of %s", rowID.Int64, table, parent)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := recordMigration(&sqlTx{Tx: tx}, migration); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// upgradeLegacySchema brings a database created by InitDB before
+// migrations to the baseline schema: it adds the columns the baseline lacks
+// and moves the tags and comments stored as JSON into their own tables.
+// InitDB-era code bound the JSON as []byte, so it's stored as a BLOB that
+// the json functions would read as JSONB unless cast to TEXT.
+func upgradeLegacySchema(tx *sql.Tx) error {
+	commands := []string{
+		"ALTER TABLE blog_entries ADD COLUMN category TEXT NULL",
+		"ALTER TABLE blog_entries ADD COLUMN contest_id INTEGER NULL",
+		"ALTER TABLE referenced_problems ADD COLUMN snippet TEXT",
+		"ALTER TABLE referenced_problems ADD COLUMN comment_id INTEGER DEFAULT 0",
+		"ALTER TABLE referenced_problems ADD COLUMN comment_author TEXT NULL",
+		"ALTER TABLE referenced_problems ADD COLUMN stale BOOLEAN DEFAULT FALSE",
+		"INSERT OR IGNORE INTO users (handle) SELECT DISTINCT author_handle FROM blog_entries WHERE author_handle != ''",
+		`INSERT OR IGNORE INTO users (handle)
+			SELECT DISTINCT json_extract(comment.value, '$.commentatorHandle') FROM blog_entries, json_each(CAST(blog_entries.comments AS TEXT)) AS comment
+			WHERE json_valid(CAST(blog_entries.comments AS TEXT)) AND comment.type = 'object' AND json_extract(comment.value, '$.commentatorHandle') != ''`,
+		`INSERT OR IGNORE INTO blog_tags (blog_id, tag)
+			SELECT blog_entries.id, tag.value FROM blog_entries, json_each(CAST(blog_entries.tags AS TEXT)) AS tag
+			WHERE json_valid(CAST(blog_entries.tags AS TEXT)) AND tag.type = 'text'`,
+		`INSERT OR IGNORE INTO comments (id, blog_id, parent_comment_id, author, rating, time, locale, text)
+			SELECT json_extract(comment.value, '$.id'), blog_entries.id,
+				(SELECT json_extract(parent.value, '$.id') FROM json_each(CAST(blog_entries.comments AS TEXT)) AS parent WHERE json_extract(parent.value, '$.id') = json_extract(comment.value, '$.parentCommentId')),
+				NULLIF(json_extract(comment.value, '$.commentatorHandle'), ''), json_extract(comment.value, '$.rating'), json_extract(comment.value, '$.creationTimeSeconds'),
+				json_extract(comment.value, '$.locale'), json_extract(comment.value, '$.text')
+			FROM blog_entries, json_each(CAST(blog_entries.comments AS TEXT)) AS comment
+			WHERE json_valid(CAST(blog_entries.comments AS TEXT)) AND comment.type = 'object'`,
+		`INSERT OR IGNORE INTO problem_tags (contest_id, idx, tag)
+			SELECT problems.contest_id, problems.idx, tag.value FROM problems, json_each(CAST(problems.tags AS TEXT)) AS tag
+			WHERE json_valid(CAST(problems.tags AS TEXT)) AND tag.type = 'text'`,
+	}
+	for _, command := range commands {
+		if _, err := tx.Exec(command); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+)
+
+// Store persists crawled blogs, problems and their mentions, the crawl
+// frontier and the daemon's schedule. SQLiteStore and PostgresStore
+// implement it with the same SQL, and OpenStore picks one by DSN.
+type Store interface {
+	SaveBlogEntry(blog *codeforces.BlogEntry) error
+	GetBlogEntry(blogID int) (*codeforces.BlogEntry, error)
+	GetBlogIDsByTags(tags []string) ([]int, error)
+
+	SaveProblem(problem *codeforces.Problem) error
+	GetContestProblems(contestID int) ([]*codeforces.Problem, error)
+	GetProblemsByTag(tag string, rating int) ([]*codeforces.Problem, error)
+
+	SaveReferencedProblem(referenced *codeforces.ReferencedProblem) error
+	GetReferencedProblems(blogID int) ([]*codeforces.ReferencedProblem, error)
+
+	SaveUser(user *codeforces.User) error
+	GetCommentsMentioningProblem(handle string, contestID int, index string) ([]codeforces.Comment, error)
+
+	SaveBlogClass(blogID int, class BlogClass, contestID int) error
+	GetBlogClass(blogID int) (BlogClass, int, error)
+	GetEditorialBlogIDs(contestID int) ([]int, error)
+
+	GetCommentHashes(blogID int) (map[int]CommentState, error)
+	SaveCommentHash(blogID int, comment *codeforces.Comment, hash string) error
+	MarkCommentDeleted(blogID, commentID int, deletedAt time.Time) error
+	GetDeletedCommentIDs(blogID int) ([]int, error)
+	MarkCommentReferencesStale(blogID, commentID int) error
+
+	GetReferenceContexts() ([]*ReferenceContext, error)
+	SaveReferenceTags(referenceID int, tags []string) error
+
+	EnqueueBlog(blogID, depth, priority int, force bool) error
+	NextFrontierEntry() (*FrontierEntry, error)
+	FinishFrontierEntry(blogID int, status string, crawlErr error) error
+	RetryFrontierEntry(blogID int, retryAt time.Time, crawlErr error) error
+	NextFrontierRetry() (time.Time, error)
+	ResetInterruptedFrontier(claimedBefore time.Time) error
+	GetFrontierEntry(blogID int) (*FrontierEntry, error)
+	CountFrontier(status string) (int, error)
+
+	GetJobState(name string) (*JobState, error)
+	SaveJobState(name string, lastRun time.Time, jobErr error) error
+	GetDueRecrawls(minRating int, now time.Time) ([]*Recrawl, error)
+	SaveRecrawl(blogID, modificationTime int, delay time.Duration, nextRun time.Time) error
+
+	Migrations() ([]Migration, error)
+	SchemaVersion() (int, error)
+	Migrate(dryRun bool) ([]Migration, error)
+	Close() error
+}
+
+// OpenStore opens a PostgresStore for postgres:// and postgresql:// URLs,
+// and a SQLiteStore at dsn, optionally prefixed with sqlite://, otherwise.
+// The store isn't migrated.
+func OpenStore(dsn string) (Store, error) {
+	switch {
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		return OpenPostgresStore(dsn)
+	default:
+		return OpenSQLiteStore(strings.TrimPrefix(dsn, "sqlite://"))
+	}
+}
+
+// sqlDB runs queries written with ? placeholders on SQLite or PostgreSQL.
+type sqlDB struct {
+	*sql.DB
+	postgres bool
+}
+
+// rebind replaces the ? placeholders of query, outside string literals,
+// with $1, $2, ... for PostgreSQL.
+func rebind(postgres bool, query string) string {
+	if !postgres || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var builder strings.Builder
+	quoted, n := false, 0
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted:
+			n++
+			fmt.Fprintf(&builder, "$%d", n)
+			continue
+		}
+		builder.WriteRune(r)
+	}
+
+	return builder.String()
+}
+
+func (d *sqlDB) Exec(query string, args ...any) (sql.Result, error) {
+	return d.DB.Exec(rebind(d.postgres, query), args...)
+}
+
+func (d *sqlDB) Query(query string, args ...any) (*sql.Rows, error) {
+	return d.DB.Query(rebind(d.postgres, query), args...)
+}
+
+func (d *sqlDB) QueryRow(query string, args ...any) *sql.Row {
+	return d.DB.QueryRow(rebind(d.postgres, query), args...)
+}
+
+func (d *sqlDB) Begin() (*sqlTx, error) {
+	tx, err := d.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqlTx{Tx: tx, postgres: d.postgres}, nil
+}
+
+type sqlTx struct {
+	*sql.Tx
+	postgres bool
+}
+
+func (t *sqlTx) Exec(query string, args ...any) (sql.Result, error) {
+	return t.Tx.Exec(rebind(t.postgres, query), args...)
+}
+
+func (t *sqlTx) Query(query string, args ...any) (*sql.Rows, error) {
+	return t.Tx.Query(rebind(t.postgres, query), args...)
+}
+
+func (t *sqlTx) QueryRow(query string, args ...any) *sql.Row {
+	return t.Tx.QueryRow(rebind(t.postgres, query), args...)
+}
@@ -0,0 +1,193 @@
+package internal
+
+import (
+	"html"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TagContextWindow is how many characters of text on each side of a problem
+// link are searched for tag keywords.
+const TagContextWindow = 300
+
+// TagConfidenceThreshold is the minimum confidence of a tag returned by
+// FindTagsForProblem.
+const TagConfidenceThreshold = 0.5
+
+type TagScore struct {
+	Tag        string
+	Confidence float64
+}
+
+type tagKeyword struct {
+	phrase string
+	weight float64
+}
+
+// tagKeywords maps Codeforces' official problem tags to phrases that hint at
+// them. Ambiguous phrases get a lower weight, so they only count together
+// with other evidence.
+var tagKeywords = map[string][]tagKeyword{
+	"2-sat":                     {{"2-sat", 1}, {"2sat", 1}, {"2 sat", 1}},
+	"binary search":             {{"binary search", 1}, {"binsearch", 1}, {"bin search", 1}, {"lower_bound", 0.6}, {"upper_bound", 0.6}, {"parallel binary search", 1}},
+	"bitmasks":                  {{"bitmask", 1}, {"bitmasks", 1}, {"bit mask", 1}, {"bitwise", 0.8}, {"xor", 0.5}, {"submask", 1}, {"submasks", 1}},
+	"brute force":               {{"brute force", 1}, {"bruteforce", 1}, {"brute-force", 1}, {"try all", 0.5}, {"iterate over all", 0.5}},
+	"chinese remainder theorem": {{"chinese remainder theorem", 1}, {"crt", 0.8}},
+	"combinatorics":             {{"combinatorics", 1}, {"binomial", 1}, {"choose", 0.3}, {"inclusion-exclusion", 1}, {"inclusion exclusion", 1}, {"stars and bars", 1}, {"catalan", 1}, {"permutations", 0.4}},
+	"constructive algorithms":   {{"constructive", 1}, {"construction", 0.6}, {"construct", 0.5}},
+	"data structures":           {{"data structure", 1}, {"data structures", 1}, {"segment tree", 1}, {"segtree", 1}, {"fenwick", 1}, {"bit tree", 1}, {"binary indexed tree", 1}, {"sparse table", 1}, {"treap", 1}, {"heap", 0.6}, {"priority queue", 0.6}, {"stack", 0.4}, {"deque", 0.4}, {"sqrt decomposition", 1}, {"lazy propagation", 1}},
+	"dfs and similar":           {{"dfs", 1}, {"bfs", 0.8}, {"depth first search", 1}, {"depth-first search", 1}, {"breadth first search", 0.8}, {"flood fill", 1}, {"connected components", 0.6}},
+	"divide and conquer":        {{"divide and conquer", 1}, {"divide & conquer", 1}, {"d&c", 1}, {"centroid decomposition", 0.8}, {"cdq", 1}},
+	"dp":                        {{"dp", 1}, {"dynamic programming", 1}, {"knapsack", 1}, {"memoization", 1}, {"memoize", 1}, {"recurrence", 0.6}, {"digit dp", 1}, {"bitmask dp", 1}},
+	"dsu":                       {{"dsu", 1}, {"disjoint set", 1}, {"disjoint sets", 1}, {"union find", 1}, {"union-find", 1}},
+	"expression parsing":        {{"expression parsing", 1}, {"parse the expression", 1}, {"parser", 0.6}, {"recursive descent", 1}},
+	"fft":                       {{"fft", 1}, {"ntt", 1}, {"fast fourier", 1}, {"convolution", 0.8}, {"polynomial multiplication", 1}},
+	"flows":                     {{"max flow", 1}, {"maxflow", 1}, {"min cut", 1}, {"mincut", 1}, {"min cost flow", 1}, {"dinic", 1}, {"network flow", 1}, {"flows", 0.8}},
+	"games":                     {{"game theory", 1}, {"nim", 1}, {"sprague-grundy", 1}, {"grundy", 1}, {"winning strategy", 1}, {"game", 0.4}},
+	"geometry":                  {{"geometry", 1}, {"convex hull", 1}, {"polygon", 0.8}, {"cross product", 1}, {"sweep line", 0.6}, {"circle", 0.4}},
+	"graph matchings":           {{"matching", 0.8}, {"bipartite matching", 1}, {"hungarian", 1}, {"kuhn", 1}, {"hopcroft-karp", 1}},
+	"graphs":                    {{"graph", 0.8}, {"graphs", 0.8}, {"vertices", 0.5}, {"edges", 0.4}, {"topological sort", 1}, {"toposort", 1}, {"scc", 1}, {"strongly connected", 1}, {"bridges", 0.6}, {"mst", 1}, {"spanning tree", 1}},
+	"greedy":                    {{"greedy", 1}, {"greedily", 1}, {"exchange argument", 1}},
+	"hashing":                   {{"hashing", 1}, {"hash", 0.8}, {"rolling hash", 1}, {"polynomial hash", 1}, {"rabin-karp", 1}},
+	"implementation":            {{"implementation", 1}, {"simulation", 0.8}, {"simulate", 0.8}, {"just implement", 1}},
+	"interactive":               {{"interactive", 1}, {"queries to the interactor", 1}, {"interactor", 1}},
+	"math":                      {{"math", 1}, {"maths", 1}, {"mathematics", 1}, {"formula", 0.6}, {"modular", 0.5}, {"parity", 0.4}},
+	"matrices":                  {{"matrix", 0.8}, {"matrices", 1}, {"matrix exponentiation", 1}, {"gaussian elimination", 1}, {"gauss", 0.6}},
+	"meet-in-the-middle":        {{"meet in the middle", 1}, {"meet-in-the-middle", 1}, {"mitm", 1}},
+	"number theory":             {{"number theory", 1}, {"gcd", 1}, {"lcm", 0.8}, {"prime", 0.6}, {"primes", 0.6}, {"sieve", 1}, {"divisors", 0.8}, {"modular inverse", 1}, {"euler's totient", 1}, {"totient", 1}},
+	"probabilities":             {{"probability", 1}, {"probabilities", 1}, {"expected value", 1}, {"expectation", 0.8}, {"random", 0.3}},
+	"schedules":                 {{"schedule", 0.6}, {"schedules", 0.6}, {"scheduling", 1}},
+	"shortest paths":            {{"shortest path", 1}, {"shortest paths", 1}, {"dijkstra", 1}, {"bellman-ford", 1}, {"bellman ford", 1}, {"floyd", 1}, {"0-1 bfs", 1}},
+	"sortings":                  {{"sort", 0.6}, {"sorting", 1}, {"sorted", 0.5}},
+	"string suffix structures":  {{"suffix array", 1}, {"suffix automaton", 1}, {"suffix tree", 1}, {"suffix structures", 1}},
+	"strings":                   {{"string", 0.6}, {"strings", 0.6}, {"kmp", 1}, {"z-function", 1}, {"z function", 1}, {"prefix function", 1}, {"aho-corasick", 1}, {"palindrome", 0.8}, {"trie", 1}, {"manacher", 1}},
+	"ternary search":            {{"ternary search", 1}, {"ternary", 0.8}},
+	"trees":                     {{"tree", 0.6}, {"trees", 0.6}, {"lca", 1}, {"lowest common ancestor", 1}, {"subtree", 0.8}, {"binary lifting", 1}, {"hld", 1}, {"heavy-light", 1}, {"rerooting", 1}, {"euler tour", 0.8}},
+	"two pointers":              {{"two pointers", 1}, {"two-pointers", 1}, {"2 pointers", 1}, {"sliding window", 1}},
+}
+
+type tagMatcher struct {
+	tag    string
+	regex  *regexp.Regexp
+	weight float64
+}
+
+var tagMatchers = buildTagMatchers()
+
+func buildTagMatchers() []tagMatcher {
+	matchers := []tagMatcher{}
+	for tag, keywords := range tagKeywords {
+		for _, keyword := range keywords {
+			matchers = append(matchers, tagMatcher{
+				tag:    tag,
+				regex:  regexp.MustCompile(`(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(keyword.phrase) + `($|[^\p{L}\p{N}])`),
+				weight: keyword.weight,
+			})
+		}
+	}
+
+	return matchers
+}
+
+var anchorRegex = regexp.MustCompile(`(?i)<a\s[^>]*href\s*=\s*["']([^"']*)["'][^>]*>`)
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+var spaceRegex = regexp.MustCompile(`\s+`)
+
+// htmlToText strips markup from content, keeping link targets in the text so
+// that mentions of a problem can be located.
+func htmlToText(content string) string {
+	text := anchorRegex.ReplaceAllString(content, " $1 ")
+	text = htmlTagRegex.ReplaceAllString(text, " ")
+	text = html.UnescapeString(text)
+	return strings.ToLower(spaceRegex.ReplaceAllString(text, " "))
+}
+
+// mentionWindow is text on one side of a mention; center is the offset of
+// the mention within it.
+type mentionWindow struct {
+	text   string
+	center int
+}
+
+// mentionWindows returns the TagContextWindow characters of text before and
+// after every occurrence of link.
+func mentionWindows(text string, link string) []mentionWindow {
+	windows := []mentionWindow{}
+	for offset := 0; link != ""; {
+		i := strings.Index(text[offset:], link)
+		if i < 0 {
+			break
+		}
+		start, end := offset+i, offset+i+len(link)
+
+		from := max(0, start-TagContextWindow)
+		to := min(len(text), end+TagContextWindow)
+		windows = append(windows,
+			mentionWindow{text: text[from:start], center: start - from},
+			mentionWindow{text: text[end:to], center: 0},
+		)
+		offset = end
+	}
+
+	return windows
+}
+
+// InferTags guesses the tags of the problem at problemUrl from the text
+// around its mentions in content. Every keyword found within
+// TagContextWindow characters of a mention adds its weight to its tag,
+// discounted by its distance from the mention, and the total is mapped to a
+// confidence in [0, 1). Tags are returned by decreasing confidence.
+func InferTags(problemUrl string, content string) []TagScore {
+	windows := mentionWindows(htmlToText(content), strings.ToLower(problemUrl))
+	if len(windows) == 0 {
+		// Without a located mention, the whole text is weak evidence.
+		windows = append(windows, mentionWindow{text: htmlToText(content), center: -1})
+	}
+
+	return scoreTags(windows)
+}
+
+// FindTagsInSection returns the tags inferred from text that is entirely
+// about one problem and starts at its mention, such as a problem's section
+// of an editorial.
+func FindTagsInSection(section string) []string {
+	tags := []string{}
+	for _, score := range scoreTags([]mentionWindow{{text: htmlToText(section), center: 0}}) {
+		if score.Confidence >= TagConfidenceThreshold {
+			tags = append(tags, score.Tag)
+		}
+	}
+
+	return tags
+}
+
+func scoreTags(windows []mentionWindow) []TagScore {
+	scores := make(map[string]float64)
+	for _, window := range windows {
+		for _, matcher := range tagMatchers {
+			for _, match := range matcher.regex.FindAllStringIndex(window.text, -1) {
+				proximity := 0.5
+				if window.center >= 0 {
+					distance := math.Abs(float64((match[0]+match[1])/2 - window.center))
+					proximity = max(0.5, 1-distance/float64(2*TagContextWindow))
+				}
+				scores[matcher.tag] += matcher.weight * proximity
+			}
+		}
+	}
+
+	tags := []TagScore{}
+	for tag, score := range scores {
+		tags = append(tags, TagScore{Tag: tag, Confidence: 1 - math.Exp(-score)})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Confidence != tags[j].Confidence {
+			return tags[i].Confidence > tags[j].Confidence
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	return tags
+}
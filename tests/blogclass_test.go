@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal"
+	codeforces "github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces/cftest"
+)
+
+const editorialContent = `<h3>1923A — Moving Chips</h3><p>Sort and count the gaps, it's greedy.</p><div class="spoiler">code</div>` +
+	`<h3><a href="https://codeforces.com/contest/1923/problem/B">1923B</a></h3><p>Dynamic programming over prefixes.</p><div class="spoiler">code</div>` +
+	`<h3>1923C — Find B</h3><p>Prefix sums.</p><div class="spoiler">code</div>` +
+	`<p>Thanks to <a href="https://codeforces.com/contest/1923/problem/A">A</a> and <a href="/contest/1923/problem/C">C</a> testers.</p>`
+
+func TestClassifyBlog(t *testing.T) {
+	tests := []struct {
+		blog codeforces.BlogEntry
+		want internal.BlogClass
+	}{
+		{codeforces.BlogEntry{Title: "<p>Codeforces Round 1 Editorial</p>"}, internal.BlogEditorial},
+		{codeforces.BlogEntry{Title: "Educational Round 162 — Tutorial", Content: editorialContent}, internal.BlogEditorial},
+		{codeforces.BlogEntry{Title: "Solutions", Content: editorialContent}, internal.BlogEditorial},
+		{codeforces.BlogEntry{Title: "Разбор задач Codeforces Round 925", Locale: "ru", OriginalLocale: "ru"}, internal.BlogEditorial},
+		{codeforces.BlogEntry{Title: "Codeforces Round 925", Tags: []string{"editorial"}}, internal.BlogEditorial},
+		{codeforces.BlogEntry{Title: "Codeforces Round #925 (Div. 3)", Content: "<p>Score distribution: 500 — 1000. Good luck!</p>"}, internal.BlogAnnouncement},
+		{codeforces.BlogEntry{Title: "Анонс Codeforces Round 925", Locale: "ru", OriginalLocale: "ru"}, internal.BlogAnnouncement},
+		{codeforces.BlogEntry{Title: "Why does my solution get TLE?"}, internal.BlogQuestion},
+		{codeforces.BlogEntry{Title: "Stuck on this one", Tags: []string{"help"}}, internal.BlogQuestion},
+		{codeforces.BlogEntry{Title: "[Tutorial] Introduction to the Li Chao tree"}, internal.BlogTutorial},
+		{codeforces.BlogEntry{Title: "Codeforces Round 925 (Div. 2) Tutorial"}, internal.BlogEditorial},
+		{codeforces.BlogEntry{Title: "Interesting problems"}, internal.BlogDiscussion},
+		{codeforces.BlogEntry{Title: "Discussion of Round 925"}, internal.BlogDiscussion},
+		{codeforces.BlogEntry{Title: "Обсуждение раунда 925", Locale: "ru"}, internal.BlogDiscussion},
+		{codeforces.BlogEntry{Title: "Обсуждение раунда 925", Locale: "ru", OriginalLocale: "ru"}, internal.BlogDiscussion},
+	}
+
+	for _, test := range tests {
+		if class := internal.ClassifyBlog(&test.blog); class != test.want {
+			t.Errorf("ClassifyBlog(%q) = %s, expected %s", test.blog.Title, class, test.want)
+		}
+	}
+}
+
+func TestCrawlerAnalyzesEditorials(t *testing.T) {
+	data := newCrawlerDataset()
+	data.BlogEntries[2].Content = editorialContent
+	data.Problems = []*codeforces.Problem{
+		{ContestID: 1923, Index: "A", Name: "Moving Chips", Tags: []string{"greedy"}},
+		{ContestID: 1923, Index: "B", Name: "Monsters Attack!", Tags: []string{"dp"}},
+		{ContestID: 1923, Index: "C", Name: "Find B", Tags: []string{"constructive algorithms"}},
+	}
+	data.ProblemStatistics = []*codeforces.ProblemStatistics{{}, {}, {}}
+	server := cftest.NewServer(data)
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	ctx := context.Background()
+	if err := internal.UpdateProblemsFromAPI(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := internal.CrawlBlogEntry(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if class, contestID, err := internal.GetBlogClass(3); err != nil || class != internal.BlogEditorial || contestID != 1923 {
+		t.Errorf("Expected an editorial of contest 1923, got %q %d %v", class, contestID, err)
+	}
+	if blogIDs, err := internal.GetEditorialBlogIDs(1923); err != nil || len(blogIDs) != 1 || blogIDs[0] != 3 {
+		t.Errorf("Expected blog 3 to be the editorial of contest 1923, got %v %v", blogIDs, err)
+	}
+
+	referenced, err := internal.GetReferencedProblems(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections := make(map[string]*codeforces.ReferencedProblem)
+	for _, problem := range referenced {
+		if problem.ProblemType == "contest" && problem.ProblemID == 1923 {
+			sections[problem.Index] = problem
+		}
+	}
+	if len(sections) != 3 {
+		t.Fatalf("Expected all 3 problems of the contest to be mapped, got %+v", referenced)
+	}
+	if snippet := sections["A"].Snippet; snippet != "1923a — moving chips sort and count the gaps, it's greedy. code" {
+		t.Errorf("Unexpected section of A: %q", snippet)
+	}
+	if tags := sections["A"].Tags; len(tags) != 1 || tags[0] != "greedy" {
+		t.Errorf("Expected A to be tagged greedy, got %v", tags)
+	}
+	if tags := sections["B"].Tags; len(tags) != 1 || tags[0] != "dp" {
+		t.Errorf("Expected B to be tagged dp, got %v", tags)
+	}
+}
+
+func TestCrawlerPolicies(t *testing.T) {
+	data := newCrawlerDataset()
+	data.BlogEntries[0].Title = "<p>Codeforces Round 1 (Div. 2) announcement</p>"
+	server := cftest.NewServer(data)
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	crawler := internal.NewCrawler()
+	crawler.Policies = map[internal.BlogClass]internal.CrawlPolicy{
+		internal.BlogAnnouncement: {AnalyzeContent: false, AnalyzeComments: true, FollowLinks: false},
+	}
+	if err := crawler.Enqueue(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawler.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if class, _, err := internal.GetBlogClass(1); err != nil || class != internal.BlogAnnouncement {
+		t.Errorf("Expected an announcement, got %q %v", class, err)
+	}
+	referenced, err := internal.GetReferencedProblems(1)
+	if err != nil || len(referenced) != 1 || referenced[0].CommentID != 10 {
+		t.Errorf("Only the comment should be analyzed, got %+v %v", referenced, err)
+	}
+	if calls := server.Calls("blogEntry.view"); calls != 1 {
+		t.Errorf("Links of the announcement should not be followed, got %d blogEntry.view calls", calls)
+	}
+}
@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal"
+	codeforces "github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces/cftest"
+)
+
+func newTagExamples() []internal.TagExample {
+	return []internal.TagExample{
+		{Problem: "contest/1/A", Text: "knapsack over the states, transitions are cheap", Tags: []string{"dp"}},
+		{Problem: "contest/2/A", Text: "states and transitions, classic knapsack", Tags: []string{"dp"}},
+		{Problem: "contest/3/A", Text: "memoize the states of the recursion", Tags: []string{"dp"}},
+		{Problem: "contest/4/A", Text: "run dijkstra from the source vertex", Tags: []string{"graphs", "shortest paths"}},
+		{Problem: "contest/5/A", Text: "dijkstra on the vertex graph with weighted edges", Tags: []string{"graphs", "shortest paths"}},
+		{Problem: "contest/6/A", Text: "the graph edges form components, dfs every vertex", Tags: []string{"graphs"}},
+	}
+}
+
+func TestTagClassifier(t *testing.T) {
+	classifier := internal.NewTagClassifier()
+	classifier.Train(newTagExamples())
+
+	if tags := classifier.Classify("a knapsack over subsets of states"); len(tags) != 1 || tags[0] != "dp" {
+		t.Errorf("Expected [dp], got %v", tags)
+	}
+	tags := strings.Join(classifier.Classify("dijkstra from every vertex"), ",")
+	if !strings.Contains(tags, "graphs") || !strings.Contains(tags, "shortest paths") || strings.Contains(tags, "dp") {
+		t.Errorf("Expected graphs and shortest paths, got %v", tags)
+	}
+
+	scores := classifier.Predict("knapsack")
+	if len(scores) != 3 || scores[0].Tag != "dp" {
+		t.Errorf("Expected a score for each of the 3 tags led by dp, got %v", scores)
+	}
+
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := classifier.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := internal.LoadTagClassifier(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Predict("knapsack"); got[0] != scores[0] {
+		t.Errorf("Loaded model predicts %v, expected %v", got, scores)
+	}
+}
+
+func TestTagClassifierEvaluate(t *testing.T) {
+	classifier := internal.NewTagClassifier()
+	classifier.Train(newTagExamples())
+
+	report := classifier.Evaluate([]internal.TagExample{
+		{Problem: "contest/7/A", Text: "knapsack states", Tags: []string{"dp"}},
+		{Problem: "contest/8/A", Text: "dijkstra on the graph", Tags: []string{"graphs", "shortest paths"}},
+		{Problem: "contest/9/A", Text: "knapsack again", Tags: []string{"greedy"}},
+	})
+
+	metrics := make(map[string]internal.TagMetrics)
+	for _, m := range report.Tags {
+		metrics[m.Tag] = m
+	}
+	if m := metrics["dp"]; m.Precision() != 0.5 || m.Recall() != 1 || m.Support != 1 {
+		t.Errorf("Unexpected dp metrics: %+v", m)
+	}
+	if m := metrics["greedy"]; m.Precision() != 0 || m.Recall() != 0 || m.Support != 1 {
+		t.Errorf("Unexpected greedy metrics: %+v", m)
+	}
+	if report.Total.Support != 4 || report.Total.TruePositives != 3 {
+		t.Errorf("Unexpected totals: %+v", report.Total)
+	}
+	if !strings.Contains(report.String(), "shortest paths") {
+		t.Errorf("Report misses a tag:\n%s", report)
+	}
+
+	train, test := internal.SplitTagExamples(newTagExamples(), 0.5)
+	if len(train)+len(test) != len(newTagExamples()) {
+		t.Errorf("Split lost examples: %d + %d", len(train), len(test))
+	}
+	again, _ := internal.SplitTagExamples(newTagExamples(), 0.5)
+	if len(again) != len(train) {
+		t.Error("Split should be deterministic")
+	}
+}
+
+func TestClassifyUntaggedProblems(t *testing.T) {
+	data := newCrawlerDataset()
+	data.BlogEntries[0].Content = `<p>Warm up with <a href="https://codeforces.com/contest/1923/problem/B">1923B</a>, it's about states and transitions.</p>`
+	data.BlogEntries[0].Comments = nil
+	data.BlogEntries[1].Content = `<p>Then <a href="https://codeforces.com/problemset/problem/1900/D">1900D</a>: more states and transitions.</p>`
+	data.Problems = []*codeforces.Problem{{ContestID: 1900, Index: "D", Name: "Small GCD", Tags: []string{"dp"}}}
+	data.ProblemStatistics = []*codeforces.ProblemStatistics{{ContestID: 1900, Index: "D"}}
+	server := cftest.NewServer(data)
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	ctx := context.Background()
+	if err := internal.UpdateProblemsFromAPI(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, blogID := range []int{1, 2} {
+		if err := internal.CrawlBlogEntry(ctx, blogID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	examples, err := internal.GetTagExamples()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(examples) != 1 || examples[0].Problem != "contest/1900/D" || !strings.Contains(examples[0].Text, "transitions") {
+		t.Fatalf("Expected one example for 1900D, got %+v", examples)
+	}
+
+	classifier := internal.NewTagClassifier()
+	classifier.Train(append(examples, internal.TagExample{Problem: "contest/1/A", Text: "shortest path", Tags: []string{"graphs"}}))
+
+	tagged, err := internal.ClassifyUntaggedProblems(classifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tagged != 2 {
+		t.Errorf("Expected both referenced problems to be tagged, got %d", tagged)
+	}
+
+	referenced, err := internal.GetReferencedProblems(1)
+	if err != nil || len(referenced) != 1 {
+		t.Fatalf("Expected 1 referenced problem, got %v %v", referenced, err)
+	}
+	if tags := referenced[0].Tags; len(tags) != 1 || tags[0] != "dp" {
+		t.Errorf("Expected 1923B to be tagged dp, got %v", tags)
+	}
+}
@@ -0,0 +1,568 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	codeforces "github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces/cftest"
+)
+
+func newTestClient(baseURL string) *codeforces.Client {
+	client := codeforces.NewClient()
+	client.BaseURL = baseURL
+	client.Limiter = nil
+	client.Retry = codeforces.RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+	return client
+}
+
+func TestClientBaseURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/user.info" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.UserAgent() != "analyzer-test" {
+			t.Errorf("Unexpected user agent %s", r.UserAgent())
+		}
+		fmt.Fprintf(w, `{"status":"OK","result":[{"handle":"%s"}]}`, r.URL.Query().Get("handles"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.UserAgent = "analyzer-test"
+
+	user, err := client.GetUserInfo(context.Background(), "tourist")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.Handle != "tourist" {
+		t.Error("User handle mismatch")
+	}
+}
+
+func TestClientSignsRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("apiKey") != "public" {
+			t.Errorf("Unexpected apiKey %s", query.Get("apiKey"))
+		}
+
+		sig := query.Get("apiSig")
+		unsigned := strings.Split(r.URL.RawQuery, "&apiSig=")[0]
+		url := codeforces.SortedParams(strings.TrimPrefix(r.URL.Path, "/api/") + "?" + unsigned)
+		if fmt.Sprintf("%s%x", sig[:6], sha512.Sum512([]byte(sig[:6]+"/"+url+"#secret"))) != sig {
+			t.Error("Invalid apiSig")
+		}
+		fmt.Fprint(w, `{"status":"OK","result":["tourist"]}`)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.PublicKey = "public"
+	client.SecretKey = "secret"
+
+	friends, err := client.GetUserFriends(context.Background(), "ArshiaDadras", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(friends) != 1 {
+		t.Error("Invalid number of friends")
+	}
+}
+
+func TestClientRetriesCallLimit(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"status":"FAILED","comment":"Call limit exceeded"}`)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, `<html>Bad Gateway</html>`)
+		default:
+			fmt.Fprint(w, `{"status":"OK","result":[]}`)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	if _, err := client.GetContestList(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+}
+
+func TestClientGivesUpAfterMaxRetries(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	if _, err := client.GetContestList(context.Background(), false); err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	if calls != client.Retry.MaxRetries+1 {
+		t.Errorf("Expected %d calls, got %d", client.Retry.MaxRetries+1, calls)
+	}
+}
+
+func TestClientRetriesTimeouts(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		fmt.Fprint(w, `{"status":"OK","result":[]}`)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.HTTPClient = &http.Client{Timeout: 50 * time.Millisecond}
+	if _, err := client.GetContestList(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected the timed out call to be retried, got %d calls", calls)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := codeforces.NewRateLimiter(20*time.Millisecond, 2)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("Rate limiter allowed 5 calls in %s", elapsed)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := codeforces.RetryPolicy{MaxRetries: 5, BaseDelay: time.Second, MaxDelay: 4 * time.Second}
+	for attempt := 0; attempt < 10; attempt++ {
+		if delay := policy.Backoff(attempt); delay > policy.MaxDelay {
+			t.Errorf("Backoff %s exceeds max delay", delay)
+		}
+	}
+
+	uncapped := codeforces.RetryPolicy{MaxRetries: 5, BaseDelay: time.Second}
+	if delay := uncapped.Backoff(4); delay < 8*time.Second || delay > 16*time.Second {
+		t.Errorf("Expected an uncapped backoff between 8s and 16s, got %s", delay)
+	}
+}
+
+func TestClientHonorsContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	client := newTestClient(server.URL)
+	if _, err := client.GetBlogEntryContents(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Request was not cancelled, took %s", elapsed)
+	}
+}
+
+func TestRateLimiterHonorsContext(t *testing.T) {
+	limiter := codeforces.NewRateLimiter(time.Hour, 1)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context canceled, got %v", err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/user.info":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"FAILED","comment":"handles: User with handle nobody not found"}`)
+		case "/api/blogEntry.view":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"FAILED","comment":"blogEntryId: Blog entry with id 1 not found"}`)
+		case "/api/contest.standings":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"FAILED","comment":"contestId: Contest with id 9999 has not started"}`)
+		case "/api/user.friends":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"FAILED","comment":"You have to be authenticated to use this method"}`)
+		case "/api/contest.list":
+			fmt.Fprint(w, `{"status":"OK","result":{`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := newTestClient(server.URL)
+
+	_, err := client.GetUserInfo(ctx, "nobody")
+	if !errors.Is(err, codeforces.ErrHandleNotFound) {
+		t.Errorf("Expected ErrHandleNotFound, got %v", err)
+	}
+	var apiErr *codeforces.APIError
+	if !errors.As(err, &apiErr) || apiErr.Method != "user.info" {
+		t.Errorf("Expected APIError for user.info, got %v", err)
+	}
+
+	if _, err := client.GetBlogEntry(ctx, 1); !errors.Is(err, codeforces.ErrBlogEntryNotFound) {
+		t.Errorf("Expected ErrBlogEntryNotFound, got %v", err)
+	}
+	if _, err := client.GetContestStandings(ctx, 9999, codeforces.StandingsOptions{Count: 10}); !errors.Is(err, codeforces.ErrContestNotStarted) {
+		t.Errorf("Expected ErrContestNotStarted, got %v", err)
+	}
+	if _, err := client.GetUserFriends(ctx, "ArshiaDadras", false); !errors.Is(err, codeforces.ErrAuthenticationRequired) {
+		t.Errorf("Expected ErrAuthenticationRequired, got %v", err)
+	}
+
+	var malformedErr *codeforces.MalformedResponseError
+	if _, err := client.GetContestList(ctx, false); !errors.As(err, &malformedErr) {
+		t.Errorf("Expected MalformedResponseError, got %v", err)
+	}
+
+	var statusErr *codeforces.HTTPStatusError
+	_, err = client.GetUserRating(ctx, "tourist")
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected HTTPStatusError with status 404, got %v", err)
+	}
+	if codeforces.IsTransient(err) {
+		t.Error("Not found errors should not be transient")
+	}
+}
+
+func TestClientStatusEndpoints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/api/problemset.recentStatus":
+			if query.Get("count") != "5" {
+				t.Errorf("Unexpected count %s", query.Get("count"))
+			}
+		case "/api/user.status":
+			if query.Get("handle") != "tourist" || query.Get("from") != "11" || query.Get("count") != "5" {
+				t.Errorf("Unexpected query %s", r.URL.RawQuery)
+			}
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"status":"OK","result":[{"id":1},{"id":2},{"id":3},{"id":4},{"id":5}]}`)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := newTestClient(server.URL)
+
+	if status, err := client.GetRecentStatus(ctx, 5, ""); err != nil || len(status) != 5 {
+		t.Errorf("Unexpected recent status result: %d submissions, %v", len(status), err)
+	}
+	if status, err := client.GetUserStatus(ctx, "tourist", 11, 5); err != nil || len(status) != 5 {
+		t.Errorf("Unexpected user status result: %d submissions, %v", len(status), err)
+	}
+	if _, err := client.GetRecentStatus(ctx, 0, ""); err == nil {
+		t.Error("Expected an error for a missing count")
+	}
+}
+
+func TestClientOptionalParameters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("lang") != "ru" {
+			t.Errorf("Missing lang parameter in %s", r.URL.RawQuery)
+		}
+
+		switch r.URL.Path {
+		case "/api/contest.standings":
+			if query.Get("participantTypes") != "CONTESTANT,OUT_OF_COMPETITION" || query.Get("asManager") != "true" {
+				t.Errorf("Unexpected query %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"status":"OK","result":{"contest":{"id":1},"problems":[],"rows":[{"party":{"teamName":"team","members":[{"handle":"a","name":"Alice"},{"handle":"b"}]},"rank":1}]}}`)
+		case "/api/contest.status":
+			if query.Get("asManager") != "true" {
+				t.Errorf("Unexpected query %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"status":"OK","result":[{"id":1,"author":{"members":[{"handle":"tourist"}],"participantType":"CONTESTANT"}}]}`)
+		case "/api/user.info":
+			if query.Get("checkHistoricHandles") != "false" {
+				t.Errorf("Unexpected query %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"status":"OK","result":[{"handle":"tourist"}]}`)
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := newTestClient(server.URL)
+	client.Lang = "ru"
+
+	standings, err := client.GetContestStandings(ctx, 1, codeforces.StandingsOptions{
+		ParticipantTypes: []string{codeforces.ParticipantContestant, codeforces.ParticipantOutOfCompetition},
+		AsManager:        true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if members := standings.Rows[0].Party.Members; len(members) != 2 || members[0].Name != "Alice" {
+		t.Error("Invalid team members")
+	}
+
+	submissions, err := client.GetContestStatus(ctx, 1, codeforces.StatusOptions{AsManager: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if submissions[0].Author.Members[0].Handle != "tourist" {
+		t.Error("Submission author mismatch")
+	}
+
+	if _, err := client.GetUsersInfo(ctx, []string{"tourist"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.GetContestRatingChanges(ctx, 100001); !errors.Is(err, codeforces.ErrRatingChangesUnavailable) {
+		t.Errorf("Expected ErrRatingChangesUnavailable, got %v", err)
+	}
+}
+
+func TestStreamContestStatus(t *testing.T) {
+	data := &cftest.Dataset{Contests: []*codeforces.Contest{{ID: 1923, Phase: "FINISHED"}}}
+	for i := 1; i <= 500; i++ {
+		data.Submissions = append(data.Submissions, &codeforces.Submission{ID: i, ContestID: 1923, Author: codeforces.Party{Members: []codeforces.Member{{Handle: "tourist"}}}})
+	}
+	server := cftest.NewServer(data)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := server.Client()
+
+	count := 0
+	err := client.StreamContestStatus(ctx, 1923, codeforces.StatusOptions{}, func(submission *codeforces.Submission) error {
+		count++
+		if submission.ID != count {
+			t.Errorf("Expected submission %d, got %d", count, submission.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 500 {
+		t.Errorf("Expected 500 submissions, got %d", count)
+	}
+
+	errStop := errors.New("stop")
+	count = 0
+	err = client.StreamContestStatus(ctx, 1923, codeforces.StatusOptions{}, func(submission *codeforces.Submission) error {
+		if count++; count == 10 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || count != 10 {
+		t.Errorf("Expected the stream to stop after 10 submissions, got %d and %v", count, err)
+	}
+
+	err = client.StreamContestStatus(ctx, 1, codeforces.StatusOptions{}, func(*codeforces.Submission) error { return nil })
+	if !errors.Is(err, codeforces.ErrContestNotFound) {
+		t.Errorf("Expected ErrContestNotFound, got %v", err)
+	}
+}
+
+func TestStreamRequestRejectsMalformedResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"OK","result":[{"handle":"tourist"},{"handle":`)
+	}))
+	defer server.Close()
+
+	count := 0
+	err := newTestClient(server.URL).StreamRatedList(context.Background(), 0, true, false, func(*codeforces.User) error {
+		count++
+		return nil
+	})
+
+	var malformedErr *codeforces.MalformedResponseError
+	if !errors.As(err, &malformedErr) {
+		t.Errorf("Expected MalformedResponseError, got %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 user before the error, got %d", count)
+	}
+}
+
+func TestSubmissionIteratorResumes(t *testing.T) {
+	data := &cftest.Dataset{
+		Users:    []*codeforces.User{{Handle: "tourist"}},
+		Contests: []*codeforces.Contest{{ID: 1923, Phase: "FINISHED"}},
+	}
+	for i := 1; i <= 25; i++ {
+		data.Submissions = append(data.Submissions, &codeforces.Submission{ID: i, ContestID: 1923, Author: codeforces.Party{Members: []codeforces.Member{{Handle: "tourist"}}}})
+	}
+	server := cftest.NewServer(data)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := server.Client()
+
+	it := client.UserSubmissions("tourist", 1, 10)
+	for i := 0; i < 12; i++ {
+		if !it.Next(ctx) {
+			t.Fatalf("Iterator stopped early: %v", it.Err())
+		}
+	}
+	if it.Value().ID != 12 || it.Offset() != 13 {
+		t.Fatalf("Unexpected position: submission %d at offset %d", it.Value().ID, it.Offset())
+	}
+
+	resumed := client.ContestSubmissions(1923, codeforces.StatusOptions{From: it.Offset()}, 10)
+	ids := []int{}
+	for resumed.Next(ctx) {
+		ids = append(ids, resumed.Value().ID)
+	}
+	if err := resumed.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 13 || ids[0] != 13 || ids[12] != 25 {
+		t.Errorf("Unexpected resumed submissions %v", ids)
+	}
+	if calls := server.Calls("contest.status"); calls != 2 {
+		t.Errorf("Expected 2 contest.status calls, got %d", calls)
+	}
+}
+
+func TestStandingsIterator(t *testing.T) {
+	standings := &codeforces.Standings{Contest: codeforces.Contest{ID: 1923}}
+	for rank := 1; rank <= 45; rank++ {
+		standings.Rows = append(standings.Rows, codeforces.RanklistRow{Rank: rank})
+	}
+	server := cftest.NewServer(&cftest.Dataset{
+		Contests:  []*codeforces.Contest{{ID: 1923, Phase: "FINISHED"}},
+		Standings: map[int]*codeforces.Standings{1923: standings},
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	it := server.Client().ContestStandings(1923, codeforces.StandingsOptions{}, 10)
+	rank := 0
+	for it.Next(ctx) {
+		if rank++; it.Value().Rank != rank {
+			t.Errorf("Expected rank %d, got %d", rank, it.Value().Rank)
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if rank != 45 {
+		t.Errorf("Expected 45 rows, got %d", rank)
+	}
+	if calls := server.Calls("contest.standings"); calls != 5 {
+		t.Errorf("Expected 5 contest.standings calls, got %d", calls)
+	}
+}
+
+func TestClientCache(t *testing.T) {
+	server := cftest.NewServer(&cftest.Dataset{
+		Users: []*codeforces.User{{Handle: "tourist"}},
+		Contests: []*codeforces.Contest{
+			{ID: 1, Phase: "FINISHED"},
+			{ID: 2, Phase: "CODING"},
+		},
+	})
+	defer server.Close()
+
+	cache, err := codeforces.NewFileCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	client := server.Client()
+	client.Cache = cache
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetUserInfo(ctx, "tourist"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.GetContestStandings(ctx, 1, codeforces.StandingsOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.GetRecentActions(ctx, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.GetUserInfo(codeforces.WithoutCache(ctx), "tourist"); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := server.Calls("user.info"); calls != 2 {
+		t.Errorf("Expected 2 user.info calls, got %d", calls)
+	}
+	if calls := server.Calls("contest.standings"); calls != 1 {
+		t.Errorf("Expected 1 contest.standings call, got %d", calls)
+	}
+	if calls := server.Calls("recentActions"); calls != 3 {
+		t.Errorf("Expected recentActions to bypass the cache, got %d calls", calls)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 4 || stats.Stores != 3 {
+		t.Errorf("Unexpected cache stats %+v", stats)
+	}
+
+	if ttl := codeforces.DefaultCachePolicy("contest.standings", []byte(`{"contest":{"id":2,"phase":"CODING"}}`)); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("Running contest standings should be cached briefly, got %s", ttl)
+	}
+	if ttl := codeforces.DefaultCachePolicy("contest.standings", []byte(`{"contest":{"id":1,"phase":"FINISHED"}}`)); ttl != codeforces.Forever {
+		t.Errorf("Finished contest standings should be cached forever, got %s", ttl)
+	}
+	for _, method := range []string{"blogEntry.view", "blogEntry.comments"} {
+		if ttl := codeforces.DefaultCachePolicy(method, []byte(`{}`)); ttl != 0 {
+			t.Errorf("%s should not be cached, got %s", method, ttl)
+		}
+	}
+}
@@ -0,0 +1,636 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal"
+	codeforces "github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces/cftest"
+)
+
+func newCrawlerDataset() *cftest.Dataset {
+	return &cftest.Dataset{
+		Users: []*codeforces.User{{Handle: "author", Rating: 2100}, {Handle: "reader", Rating: 1500}},
+		BlogEntries: []*codeforces.BlogEntry{
+			{
+				ID:                      1,
+				AuthorHandle:            "author",
+				Title:                   "<p>Interesting problems</p>",
+				ModificationTimeSeconds: 100,
+				Content:                 `<p>Try <a href="https://codeforces.com/contest/1923/problem/B">1923B</a> and read <a href="https://codeforces.com/blog/entry/2">part two</a>, <a href="https://codeforces.com/blog/entry/3">the editorial</a> and <a href="https://codeforces.com/blog/entry/404">a deleted blog</a>.</p>`,
+				Comments: []codeforces.Comment{
+					{ID: 10, CommentatorHandle: "reader", Text: `<p>Also <a href="https://codeforces.com/gym/104114/problem/C">this gym problem</a>.</p>`},
+				},
+			},
+			{
+				ID:                      2,
+				AuthorHandle:            "author",
+				Title:                   "<p>Interesting problems, part two</p>",
+				ModificationTimeSeconds: 200,
+				Content:                 `<p>Back to <a href="https://codeforces.com/blog/entry/1">part one</a>. Solve <a href="https://codeforces.com/problemset/problem/1900/D">1900D</a>.</p>`,
+			},
+			{
+				ID:           3,
+				AuthorHandle: "author",
+				Title:        "<p>Codeforces Round 1 Editorial</p>",
+				Content:      `<p><a href="https://codeforces.com/contest/1/problem/A">1A</a></p>`,
+			},
+		},
+	}
+}
+
+func openTestDB(t *testing.T) {
+	t.Helper()
+
+	if err := internal.OpenDB(filepath.Join(t.TempDir(), "db.sqlite3")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { internal.CloseDB() })
+}
+
+func useClient(t *testing.T, client *codeforces.Client) {
+	t.Helper()
+
+	previous := codeforces.DefaultClient
+	codeforces.DefaultClient = client
+	t.Cleanup(func() { codeforces.DefaultClient = previous })
+}
+
+func TestCrawlBlogEntry(t *testing.T) {
+	server := cftest.NewServer(newCrawlerDataset())
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	if err := internal.CrawlBlogEntry(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, blogID := range []int{1, 2} {
+		if _, err := internal.GetBlogEntry(blogID); err != nil {
+			t.Errorf("Blog %d was not saved: %v", blogID, err)
+		}
+	}
+	if class, _, err := internal.GetBlogClass(3); err != nil || class != internal.BlogEditorial {
+		t.Errorf("Editorial should have been saved and classified, got %q %v", class, err)
+	}
+
+	referenced, err := internal.GetReferencedProblems(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(referenced) != 2 {
+		t.Fatalf("Expected 2 referenced problems on blog 1, got %d", len(referenced))
+	}
+	if referenced[0].ProblemType != "contest" || referenced[0].ProblemID != 1923 || referenced[0].Index != "B" {
+		t.Errorf("Unexpected referenced problem %+v", referenced[0])
+	}
+	if referenced[1].ProblemType != "gym" || referenced[1].ProblemID != 104114 || referenced[1].Index != "C" {
+		t.Errorf("Unexpected referenced problem %+v", referenced[1])
+	}
+
+	if calls := server.Calls("blogEntry.view"); calls != 4 {
+		t.Errorf("Expected every blog to be visited once, got %d blogEntry.view calls", calls)
+	}
+}
+
+func TestCrawlBlogEntryRetriesCallLimit(t *testing.T) {
+	server := cftest.NewServer(newCrawlerDataset())
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	server.FailWithCallLimit(2)
+	if err := internal.CrawlBlogEntry(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := internal.GetBlogEntry(2); err != nil {
+		t.Errorf("Blog 2 was not saved: %v", err)
+	}
+}
+
+func TestFakeServerAuthentication(t *testing.T) {
+	server := cftest.NewServer(&cftest.Dataset{
+		Users:   []*codeforces.User{{Handle: "author"}},
+		Friends: map[string][]string{"author": {"reader"}},
+	})
+	defer server.Close()
+	server.PublicKey, server.SecretKey = "public", "secret"
+
+	ctx := context.Background()
+	anonymous := server.Client()
+	anonymous.PublicKey, anonymous.SecretKey = "", ""
+	if _, err := anonymous.GetUserFriends(ctx, "author", false); !errors.Is(err, codeforces.ErrAuthenticationRequired) {
+		t.Errorf("Expected ErrAuthenticationRequired, got %v", err)
+	}
+
+	wrongSecret := server.Client()
+	wrongSecret.SecretKey = "wrong"
+	if _, err := wrongSecret.GetUserFriends(ctx, "author", false); !errors.Is(err, codeforces.ErrAuthenticationRequired) {
+		t.Errorf("Expected ErrAuthenticationRequired for a bad signature, got %v", err)
+	}
+
+	friends, err := server.Client().GetUserFriends(ctx, "author", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(friends) != 1 || friends[0] != "reader" {
+		t.Errorf("Unexpected friends %v", friends)
+	}
+}
+
+func TestRecrawlSkipsUnchangedBlogs(t *testing.T) {
+	server := cftest.NewServer(newCrawlerDataset())
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	ctx := context.Background()
+	if err := internal.CrawlBlogEntry(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	pages := server.Calls("blog")
+
+	if err := internal.CrawlBlogEntry(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if calls := server.Calls("blog"); calls != pages {
+		t.Errorf("Unchanged blog was scraped again (%d page loads instead of %d)", calls, pages)
+	}
+
+	server.Update(func(data *cftest.Dataset) {
+		data.BlogEntries[1].Comments = append(data.BlogEntries[1].Comments, codeforces.Comment{ID: 20, Text: `<a href="https://codeforces.com/contest/1923/problem/F">F</a>`})
+	})
+	if err := internal.CrawlBlogEntry(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if calls := server.Calls("blog"); calls != pages {
+		t.Errorf("Blog with only new comments was scraped again (%d page loads instead of %d)", calls, pages)
+	}
+	if blog, err := internal.GetBlogEntry(2); err != nil || len(blog.Comments) != 1 {
+		t.Errorf("New comment was not saved: %v", err)
+	}
+
+	server.Update(func(data *cftest.Dataset) {
+		data.BlogEntries[1].ModificationTimeSeconds++
+		data.BlogEntries[1].Content = `<p>Updated</p>`
+	})
+	if err := internal.CrawlBlogEntry(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if calls := server.Calls("blog"); calls != pages+1 {
+		t.Errorf("Modified blog was not scraped again")
+	}
+	if blog, err := internal.GetBlogEntry(2); err != nil || blog.Content != `<p>Updated</p>` {
+		t.Errorf("Modified content was not saved: %v", err)
+	}
+}
+
+func TestCrawlerMaxDepth(t *testing.T) {
+	data := &cftest.Dataset{}
+	for id := 10; id < 15; id++ {
+		data.BlogEntries = append(data.BlogEntries, &codeforces.BlogEntry{
+			ID:      id,
+			Title:   "<p>Chain</p>",
+			Content: fmt.Sprintf(`<a href="https://codeforces.com/blog/entry/%d">next</a>`, id+1),
+		})
+	}
+	server := cftest.NewServer(data)
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	crawler := internal.NewCrawler()
+	crawler.MaxDepth = 2
+	if err := crawler.Enqueue(10, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawler.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for id := 10; id <= 12; id++ {
+		if entry, err := internal.GetFrontierEntry(id); err != nil || entry.Status != internal.FrontierDone {
+			t.Errorf("Blog %d should have been crawled: %+v %v", id, entry, err)
+		}
+	}
+	if _, err := internal.GetFrontierEntry(13); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Blog 13 is beyond the max depth, got %v", err)
+	}
+}
+
+func TestFrontierPriority(t *testing.T) {
+	openTestDB(t)
+
+	for _, entry := range []internal.FrontierEntry{{BlogID: 5, Depth: 1}, {BlogID: 6, Priority: 100}, {BlogID: 7}} {
+		if err := internal.EnqueueBlog(entry.BlogID, entry.Depth, entry.Priority, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, expected := range []int{6, 7, 5} {
+		entry, err := internal.NextFrontierEntry()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.BlogID != expected {
+			t.Errorf("Expected blog %d, got %d", expected, entry.BlogID)
+		}
+	}
+	if _, err := internal.NextFrontierEntry(); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected an empty frontier, got %v", err)
+	}
+}
+
+func TestCrawlerResumesInterruptedCrawl(t *testing.T) {
+	server := cftest.NewServer(newCrawlerDataset())
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	if err := internal.EnqueueBlog(2, 0, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := internal.NextFrontierEntry(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := internal.NewCrawler().Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the cancelled crawl to stop, got %v", err)
+	}
+	if entry, err := internal.GetFrontierEntry(2); err != nil || entry.Status != internal.FrontierInProgress {
+		t.Fatalf("Blog claimed by another crawler should be left alone: %+v %v", entry, err)
+	}
+
+	crawler := internal.NewCrawler()
+	crawler.ClaimTimeout = 0
+	if err := crawler.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the cancelled crawl to stop, got %v", err)
+	}
+	if entry, err := internal.GetFrontierEntry(2); err != nil || entry.Status != internal.FrontierPending {
+		t.Fatalf("Blog whose claim timed out should be pending again: %+v %v", entry, err)
+	}
+
+	if err := internal.NewCrawler().Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, blogID := range []int{1, 2} {
+		if entry, err := internal.GetFrontierEntry(blogID); err != nil || entry.Status != internal.FrontierDone {
+			t.Errorf("Blog %d should have been crawled: %+v %v", blogID, entry, err)
+		}
+	}
+	if entry, err := internal.GetFrontierEntry(404); err != nil || entry.Status != internal.FrontierSkipped {
+		t.Errorf("Deleted blog should have been skipped: %+v %v", entry, err)
+	}
+}
+
+func TestCrawlerRetriesTransientErrors(t *testing.T) {
+	server := cftest.NewServer(newCrawlerDataset())
+	defer server.Close()
+
+	client := server.Client()
+	client.Retry = codeforces.RetryPolicy{}
+	useClient(t, client)
+	openTestDB(t)
+
+	crawler := internal.NewCrawler()
+	crawler.MaxDepth = 0
+	crawler.Retry = codeforces.RetryPolicy{MaxRetries: 2}
+
+	// Without a backoff failed blogs are retried by the same run.
+	server.FailWithCallLimit(2)
+	if err := crawler.Enqueue(2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawler.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := internal.GetFrontierEntry(2); err != nil || entry.Status != internal.FrontierDone || entry.Attempts != 2 {
+		t.Errorf("Expected blog 2 to be crawled on the third attempt, got %+v %v", entry, err)
+	}
+
+	// Once the retries run out the blog fails for good.
+	server.FailWithCallLimit(10)
+	if err := crawler.Enqueue(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawler.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := internal.GetFrontierEntry(1); err != nil || entry.Status != internal.FrontierFailed || entry.Attempts != 2 {
+		t.Errorf("Expected blog 1 to fail after 2 retries, got %+v %v", entry, err)
+	}
+
+	// Otherwise they wait in the frontier for a later run.
+	crawler.Retry.BaseDelay, crawler.Retry.MaxDelay = time.Hour, time.Hour
+	server.FailWithCallLimit(1)
+	if err := crawler.Enqueue(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawler.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := internal.GetFrontierEntry(1); err != nil || entry.Status != internal.FrontierPending || entry.Attempts != 1 {
+		t.Errorf("Expected blog 1 to wait for a retry, got %+v %v", entry, err)
+	}
+	if retryAt, err := internal.NextFrontierRetry(); err != nil || time.Until(retryAt) < 29*time.Minute {
+		t.Errorf("Expected blog 1 to be retried in about an hour, got %s %v", retryAt, err)
+	}
+}
+
+func TestCrawlerWorkers(t *testing.T) {
+	data := newCrawlerDataset()
+	for id := 20; id < 40; id++ {
+		data.BlogEntries = append(data.BlogEntries, &codeforces.BlogEntry{
+			ID:      id,
+			Title:   "<p>Hub</p>",
+			Content: `<a href="https://codeforces.com/blog/entry/1">hub</a> <a href="https://codeforces.com/contest/1923/problem/A">A</a>`,
+		})
+	}
+	server := cftest.NewServer(data)
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	crawler := internal.NewCrawler()
+	crawler.Workers = 4
+	for id := 20; id < 40; id++ {
+		if err := crawler.Enqueue(id, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := crawler.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := internal.CountFrontier(internal.FrontierDone); err != nil || count != 23 {
+		t.Errorf("Expected 23 crawled blogs, got %d (%v)", count, err)
+	}
+	if calls := server.Calls("blogEntry.view"); calls != 24 {
+		t.Errorf("Expected every blog to be visited once, got %d blogEntry.view calls", calls)
+	}
+}
+
+func TestCrawlerDrainsInFlightBlogs(t *testing.T) {
+	data := &cftest.Dataset{}
+	for id := 10; id < 15; id++ {
+		data.BlogEntries = append(data.BlogEntries, &codeforces.BlogEntry{ID: id, Title: "<p>Slow</p>"})
+	}
+	server := cftest.NewServer(data)
+	defer server.Close()
+	server.Latency = 30 * time.Millisecond
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	crawler := internal.NewCrawler()
+	crawler.Workers = 2
+	for id := 10; id < 15; id++ {
+		if err := crawler.Enqueue(id, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Millisecond)
+	defer cancel()
+	if err := crawler.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the crawl to stop at the deadline, got %v", err)
+	}
+
+	done, err := internal.CountFrontier(internal.FrontierDone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := internal.CountFrontier(internal.FrontierPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done != 2 || pending != 3 {
+		t.Errorf("Expected the 2 in-flight blogs to finish and 3 to stay pending, got %d done and %d pending", done, pending)
+	}
+}
+
+func TestCrawlerSeeders(t *testing.T) {
+	data := newCrawlerDataset()
+	data.BlogEntries[0].Tags = []string{"graphs"}
+	data.RecentActions = []*codeforces.RecentAction{
+		{BlogEntry: codeforces.BlogEntry{ID: 2, Rating: 50}},
+		{BlogEntry: codeforces.BlogEntry{ID: 2, Rating: 50}, Comment: codeforces.Comment{ID: 30}},
+	}
+	server := cftest.NewServer(data)
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	ctx := context.Background()
+	crawler := internal.NewCrawler()
+	crawler.MaxDepth = 0
+
+	if seeded := crawler.Seed(ctx, internal.SeedConfig{RecentActions: 10}.Seeders()...); seeded != 1 {
+		t.Errorf("Expected 1 blog from recent actions, got %d", seeded)
+	}
+	if entry, err := internal.GetFrontierEntry(2); err != nil || entry.Priority != 50 {
+		t.Errorf("Recent blog should be enqueued with its rating: %+v %v", entry, err)
+	}
+	if err := crawler.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if seeded := crawler.Seed(ctx, internal.AuthorsSeeder{Handles: []string{"author"}}); seeded != 0 {
+		t.Errorf("Crawled blogs of an author should not be seeded again, got %d", seeded)
+	}
+	server.Update(func(data *cftest.Dataset) { data.BlogEntries[0].ModificationTimeSeconds++ })
+	if seeded := crawler.Seed(ctx, internal.AuthorsSeeder{Handles: []string{"author"}}); seeded != 1 {
+		t.Errorf("Expected the modified blog to be seeded, got %d", seeded)
+	}
+
+	if seeded := crawler.Seed(ctx, internal.TagsSeeder{Tags: []string{"graphs", "dp"}}); seeded != 1 {
+		t.Errorf("Expected 1 blog tagged graphs, got %d", seeded)
+	}
+
+	if seeded := crawler.Seed(ctx, internal.RangeSeeder{From: 1, To: 5}); seeded != 5 {
+		t.Errorf("Expected 5 blogs from the range, got %d", seeded)
+	}
+	if entry, err := internal.GetFrontierEntry(5); err != nil || entry.Status != internal.FrontierPending {
+		t.Errorf("Unvisited blog from the range should be pending: %+v %v", entry, err)
+	}
+	if entry, err := internal.GetFrontierEntry(2); err != nil || entry.Status != internal.FrontierDone {
+		t.Errorf("Visited blog from the range should stay done: %+v %v", entry, err)
+	}
+}
+
+func TestCrawlerStoresMentionSnippets(t *testing.T) {
+	data := newCrawlerDataset()
+	data.BlogEntries[0].Content = `<p>Intro with no links.</p><ul><li>Warm up: <a href="https://codeforces.com/contest/1923/problem/B">1923B</a>, a nice dp.</li><li>Unrelated greedy item.</li></ul>`
+	server := cftest.NewServer(data)
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	crawler := internal.NewCrawler()
+	crawler.MaxDepth = 0
+	if err := crawler.Enqueue(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawler.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	referenced, err := internal.GetReferencedProblems(1)
+	if err != nil || len(referenced) != 2 {
+		t.Fatalf("Expected 2 referenced problems, got %v %v", referenced, err)
+	}
+
+	blogMention, commentMention := referenced[0], referenced[1]
+	if blogMention.Snippet != "Warm up: 1923B, a nice dp." || blogMention.CommentID != 0 || blogMention.CommentAuthor != "" {
+		t.Errorf("Unexpected blog mention: %+v", blogMention)
+	}
+	if len(blogMention.Tags) != 1 || blogMention.Tags[0] != "dp" {
+		t.Errorf("Tags should be inferred from the list item only, got %v", blogMention.Tags)
+	}
+	if commentMention.Snippet != "Also this gym problem." || commentMention.CommentID != 10 || commentMention.CommentAuthor != "reader" {
+		t.Errorf("Unexpected comment mention: %+v", commentMention)
+	}
+}
+
+func TestSnippetsOfLinksWithQueryStrings(t *testing.T) {
+	openTestDB(t)
+
+	content := `<p>Intro with no links.</p><ul><li>Warm up: <a href="https://codeforces.com/contest/1923/problem/B?locale=en&mobile=false">1923B</a>, a nice dp.</li><li>Then codeforces.com/problemset/problem/1900/D?locale=ru&x=1 is greedy.</li></ul>`
+	for _, link := range codeforces.FindLinks(content) {
+		if err := internal.AnalyzeProblem(link, 1, content, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	referenced, err := internal.GetReferencedProblems(1)
+	if err != nil || len(referenced) != 2 {
+		t.Fatalf("Expected 2 referenced problems, got %v %v", referenced, err)
+	}
+	if snippet := referenced[0].Snippet; snippet != "Warm up: 1923B, a nice dp." {
+		t.Errorf("Expected the list item linking to 1923B, got %q", snippet)
+	}
+	if snippet := referenced[1].Snippet; snippet != "Then codeforces.com/problemset/problem/1900/D?locale=ru&x=1 is greedy." {
+		t.Errorf("Expected the list item mentioning 1900D, got %q", snippet)
+	}
+}
+
+func TestOpenDBAddsSnippetColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.sqlite3")
+	execAll(t, path, baselineSchema)
+	execArgs(t, path, "INSERT INTO referenced_problems (blog_id, problem_type, problem_id, idx, tags) VALUES (?, ?, ?, ?, ?)", 1, "contest", 1923, "B", []byte(`["dp"]`))
+
+	if err := internal.OpenDB(path); err != nil {
+		t.Fatal(err)
+	}
+	defer internal.CloseDB()
+
+	if err := internal.SaveReferencedProblem(&codeforces.ReferencedProblem{BlogID: 1, ProblemType: "contest", ProblemID: 1923, Index: "B", Tags: []string{"greedy"}, Snippet: "1923B"}); err != nil {
+		t.Fatal(err)
+	}
+
+	referenced, err := internal.GetReferencedProblems(1)
+	if err != nil || len(referenced) != 1 {
+		t.Fatalf("Expected the old mention to be updated, got %v %v", referenced, err)
+	}
+	if referenced[0].Snippet != "1923B" || len(referenced[0].Tags) != 2 {
+		t.Errorf("Unexpected referenced problem: %+v", referenced[0])
+	}
+}
+
+func TestCrawlerDiffsComments(t *testing.T) {
+	server := cftest.NewServer(newCrawlerDataset())
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	crawler := internal.NewCrawler()
+	crawler.MaxDepth = 0
+	crawl := func() {
+		t.Helper()
+
+		if err := crawler.Enqueue(1, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := crawler.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	references := func() map[string]*codeforces.ReferencedProblem {
+		t.Helper()
+
+		referenced, err := internal.GetReferencedProblems(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byProblem := make(map[string]*codeforces.ReferencedProblem)
+		for _, problem := range referenced {
+			byProblem[fmt.Sprintf("%s/%d/%s", problem.ProblemType, problem.ProblemID, problem.Index)] = problem
+		}
+		return byProblem
+	}
+
+	crawl()
+
+	server.Update(func(data *cftest.Dataset) {
+		data.BlogEntries[0].Comments = []codeforces.Comment{
+			{ID: 10, CommentatorHandle: "reader", Text: `<p>Oops, I meant <a href="https://codeforces.com/contest/1/problem/A">1A</a>.</p>`},
+			{ID: 11, CommentatorHandle: "author", Text: `<p>Try <a href="https://codeforces.com/problemset/problem/1900/D">1900D</a>.</p>`},
+		}
+	})
+	crawl()
+
+	byProblem := references()
+	if problem := byProblem["gym/104114/C"]; problem == nil || !problem.Stale {
+		t.Errorf("Reference removed from an edited comment should be stale: %+v", problem)
+	}
+	if problem := byProblem["contest/1/A"]; problem == nil || problem.Stale || problem.CommentID != 10 {
+		t.Errorf("Reference added by an edit should be fresh: %+v", problem)
+	}
+	if problem := byProblem["problemset/1900/D"]; problem == nil || problem.Stale || problem.CommentID != 11 {
+		t.Errorf("Reference from a new comment should be fresh: %+v", problem)
+	}
+	if problem := byProblem["contest/1923/B"]; problem == nil || problem.Stale {
+		t.Errorf("Reference from the blog should not be affected: %+v", problem)
+	}
+
+	server.Update(func(data *cftest.Dataset) { data.BlogEntries[0].Comments = data.BlogEntries[0].Comments[:1] })
+	crawl()
+
+	byProblem = references()
+	if problem := byProblem["problemset/1900/D"]; problem == nil || !problem.Stale {
+		t.Errorf("Reference from a deleted comment should be stale: %+v", problem)
+	}
+	if problem := byProblem["contest/1/A"]; problem == nil || problem.Stale {
+		t.Errorf("Reference from an unchanged comment should stay fresh: %+v", problem)
+	}
+	if deleted, err := internal.GetDeletedCommentIDs(1); err != nil || len(deleted) != 1 || deleted[0] != 11 {
+		t.Errorf("Expected comment 11 to be recorded as deleted, got %v %v", deleted, err)
+	}
+
+	diff, err := internal.DiffComments(1, []codeforces.Comment{{ID: 10, Text: `<p>Oops, I meant <a href="https://codeforces.com/contest/1/problem/A">1A</a>.</p>`}, {ID: 11, Text: "back"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].ID != 11 || len(diff.Edited) != 0 || len(diff.Deleted) != 0 {
+		t.Errorf("A deleted comment showing up again should count as added: %+v", diff)
+	}
+}
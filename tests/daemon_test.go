@@ -0,0 +1,221 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal"
+	codeforces "github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces/cftest"
+)
+
+func newTestDaemon() *internal.Daemon {
+	daemon := internal.NewDaemon()
+	daemon.Crawler.MaxDepth = 0
+	return daemon
+}
+
+// runDaemonUntil runs the daemon until done reports true and then stops it.
+func runDaemonUntil(t *testing.T, daemon *internal.Daemon, done func() bool) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() { errs <- daemon.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatal("Daemon didn't finish its work in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("Expected daemon to stop with context.Canceled, got %v", err)
+	}
+}
+
+func TestDaemonRunsAndResumesSchedule(t *testing.T) {
+	data := newCrawlerDataset()
+	data.Problems = []*codeforces.Problem{{ContestID: 1923, Index: "B", Name: "Monsters", Type: "PROGRAMMING"}}
+	data.ProblemStatistics = []*codeforces.ProblemStatistics{{ContestID: 1923, Index: "B", SolvedCount: 10}}
+	data.RecentActions = []*codeforces.RecentAction{{TimeSeconds: int(time.Now().Unix()), BlogEntry: codeforces.BlogEntry{ID: 2}}}
+	server := cftest.NewServer(data)
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	daemon := newTestDaemon()
+	runDaemonUntil(t, daemon, func() bool {
+		schedule, err := daemon.Schedule()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, job := range schedule {
+			if job.LastRun.IsZero() {
+				return false
+			}
+		}
+		entry, err := internal.GetFrontierEntry(2)
+		return err == nil && entry.Status == internal.FrontierDone
+	})
+
+	if _, err := internal.GetBlogEntry(2); err != nil {
+		t.Errorf("Recently active blog was not crawled: %v", err)
+	}
+
+	schedule, err := newTestDaemon().Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, job := range schedule {
+		if job.LastError != "" {
+			t.Errorf("Job %s failed: %s", job.Name, job.LastError)
+		}
+		if !job.NextRun.After(time.Now()) {
+			t.Errorf("Job %s should not be due right after running, next run at %v", job.Name, job.NextRun)
+		}
+	}
+
+	// A restarted daemon must not repeat jobs that aren't due yet.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := newTestDaemon().Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected restarted daemon to stop with the deadline, got %v", err)
+	}
+	if calls := server.Calls("problemset.problems"); calls != 1 {
+		t.Errorf("Expected problemset to be fetched once, got %d", calls)
+	}
+	if calls := server.Calls("recentActions"); calls != 1 {
+		t.Errorf("Expected recent actions to be polled once, got %d", calls)
+	}
+}
+
+func TestDaemonRecrawlBackoff(t *testing.T) {
+	data := newCrawlerDataset()
+	data.BlogEntries[1].Rating = 150
+	server := cftest.NewServer(data)
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	ctx := context.Background()
+	if err := internal.CrawlBlogEntry(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	daemon := newTestDaemon()
+	var recrawl internal.Job
+	for _, job := range daemon.Jobs() {
+		if job.Name == "recrawl" {
+			recrawl = job
+		}
+	}
+
+	delayOf := func(blogID int) time.Duration {
+		t.Helper()
+
+		due, err := internal.GetDueRecrawls(daemon.RecrawlMinRating, time.Now().Add(daemon.RecrawlMaxDelay+time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, recrawl := range due {
+			if recrawl.BlogID == blogID {
+				return recrawl.Delay
+			}
+		}
+		t.Fatalf("Blog %d has no recrawl scheduled", blogID)
+		return 0
+	}
+
+	if err := recrawl.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := internal.GetFrontierEntry(2); err != nil || entry.Status != internal.FrontierPending || entry.Priority != 150 {
+		t.Errorf("High-rated blog should be enqueued with its rating: %+v %v", entry, err)
+	}
+	if entry, err := internal.GetFrontierEntry(1); err != nil || entry.Status != internal.FrontierDone {
+		t.Errorf("Low-rated blog should not be re-crawled: %+v %v", entry, err)
+	}
+	if delay := delayOf(2); delay != daemon.RecrawlBaseDelay {
+		t.Errorf("Expected first delay %v, got %v", daemon.RecrawlBaseDelay, delay)
+	}
+
+	if due, err := internal.GetDueRecrawls(daemon.RecrawlMinRating, time.Now()); err != nil || len(due) != 0 {
+		t.Errorf("Blog should not be due right after being scheduled: %v %v", due, err)
+	}
+
+	// Unchanged since the last re-crawl: back off.
+	if err := internal.SaveRecrawl(2, 200, daemon.RecrawlBaseDelay, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := recrawl.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if delay := delayOf(2); delay != 2*daemon.RecrawlBaseDelay {
+		t.Errorf("Expected unchanged blog to back off to %v, got %v", 2*daemon.RecrawlBaseDelay, delay)
+	}
+
+	// Modified since the last re-crawl: start over.
+	if err := internal.SaveRecrawl(2, 150, 8*daemon.RecrawlBaseDelay, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := recrawl.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if delay := delayOf(2); delay != daemon.RecrawlBaseDelay {
+		t.Errorf("Expected modified blog to go back to %v, got %v", daemon.RecrawlBaseDelay, delay)
+	}
+}
+
+func TestDaemonRetriesFailedJobWithoutMovingWatermark(t *testing.T) {
+	data := newCrawlerDataset()
+	data.RecentActions = []*codeforces.RecentAction{{TimeSeconds: int(time.Now().Unix()), BlogEntry: codeforces.BlogEntry{ID: 2}}}
+	server := cftest.NewServer(data)
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	since := time.Unix(time.Now().Unix()-3600, 0)
+	if err := internal.SaveJobState("recent-actions", since, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := internal.SaveJobState("recent-actions", time.Now(), errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+
+	schedule, err := newTestDaemon().Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, job := range schedule {
+		if job.Name == "recent-actions" && (!job.LastRun.Equal(since) || job.LastError != "boom") {
+			t.Errorf("Failed run should keep the previous watermark %v: %+v", since, job)
+		}
+	}
+
+	before := time.Unix(time.Now().Unix(), 0)
+	daemon := newTestDaemon()
+	runDaemonUntil(t, daemon, func() bool {
+		state, err := internal.GetJobState("recent-actions")
+		return err == nil && state.LastError == ""
+	})
+
+	state, err := internal.GetJobState("recent-actions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.LastRun.Before(before) {
+		t.Errorf("Successful run should advance the watermark past %v, got %v", before, state.LastRun)
+	}
+	if entry, err := internal.GetFrontierEntry(2); err != nil || entry.Status == "" {
+		t.Errorf("Blog active since the watermark should be enqueued: %+v %v", entry, err)
+	}
+}
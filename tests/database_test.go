@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal"
+	codeforces "github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+)
+
+func TestNormalizedQueries(t *testing.T) {
+	openTestDB(t)
+
+	problems := []*codeforces.Problem{
+		{ContestID: 1923, Index: "B", Name: "Monsters Attack!", Rating: 1800, Tags: []string{"dp", "greedy"}},
+		{ContestID: 1923, Index: "C", Name: "Find B", Rating: 1800, Tags: []string{"math"}},
+		{ContestID: 1900, Index: "D", Name: "Small GCD", Rating: 2000, Tags: []string{"dp"}},
+	}
+	for _, problem := range problems {
+		if err := internal.SaveProblem(problem); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Tags dropped by the API are dropped here too.
+	problems[2].Tags = []string{"math"}
+	if err := internal.SaveProblem(problems[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	tagged, err := internal.GetProblemsByTag("dp", 1800)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tagged) != 1 || tagged[0].Index != "B" || !reflect.DeepEqual(tagged[0].Tags, []string{"dp", "greedy"}) {
+		t.Errorf("Unexpected problems tagged dp with rating 1800: %+v", tagged)
+	}
+	if tagged, err := internal.GetProblemsByTag("math", 0); err != nil || len(tagged) != 2 {
+		t.Errorf("Expected two problems tagged math, got %v %v", tagged, err)
+	}
+
+	blog := &codeforces.BlogEntry{
+		ID:           1,
+		AuthorHandle: "author",
+		Title:        "Problems",
+		Tags:         []string{"dp", "greedy"},
+		Comments: []codeforces.Comment{
+			{ID: 10, CommentatorHandle: "reader", CreationTimeSeconds: 1, Text: `<a href="https://codeforces.com/problemset/problem/1923/B">this</a>`},
+			{ID: 11, CommentatorHandle: "author", CreationTimeSeconds: 2, ParentCommentId: 10, Text: `<a href="https://codeforces.com/contest/1923/problem/B">1923B</a>`},
+			{ID: 12, CommentatorHandle: "reader", CreationTimeSeconds: 3, ParentCommentId: 9, Text: `<a href="https://codeforces.com/contest/1923/problem/C">1923C</a>`},
+		},
+	}
+	if err := internal.SaveBlogEntry(blog); err != nil {
+		t.Fatal(err)
+	}
+	internal.AnalyzeProblemsOnComments(blog)
+
+	saved, err := internal.GetBlogEntry(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(saved.Tags, blog.Tags) || len(saved.Comments) != 3 {
+		t.Fatalf("Unexpected saved blog: %+v", saved)
+	}
+	if saved.Comments[1].ParentCommentId != 10 || saved.Comments[2].ParentCommentId != 0 {
+		t.Errorf("Replies to unknown comments should be top level, got %+v", saved.Comments)
+	}
+
+	comments, err := internal.GetCommentsMentioningProblem("reader", 1923, "B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || comments[0].ID != 10 {
+		t.Errorf("Expected comment 10 by reader to mention 1923B, got %+v", comments)
+	}
+
+	if blogIDs, err := internal.GetBlogIDsByTags([]string{"greedy", "implementation"}); err != nil || !reflect.DeepEqual(blogIDs, []int{1}) {
+		t.Errorf("Expected blog 1 to be tagged greedy, got %v %v", blogIDs, err)
+	}
+}
+
+func TestOpenDBMovesJSONColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.sqlite3")
+	execAll(t, path, baselineSchema)
+	execArgs(t, path, "INSERT INTO blog_entries VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", 1, "en", 1, "author", "Problems", "", "en", 1, true, []byte(`["dp"]`), 5,
+		[]byte(`[{"id":10,"commentatorHandle":"reader","text":"first","creationTimeSeconds":1},{"id":11,"commentatorHandle":"author","text":"reply","creationTimeSeconds":2,"parentCommentId":10}]`))
+	execArgs(t, path, "INSERT INTO problems VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", 1923, "", "B", "Monsters Attack!", "PROGRAMMING", 0, 1800, []byte(`["dp","greedy"]`), 100)
+
+	// Opening twice must not move anything again.
+	for i := 0; i < 2; i++ {
+		if err := internal.OpenDB("file:" + path + "?cache=shared"); err != nil {
+			t.Fatal(err)
+		}
+		internal.CloseDB()
+	}
+	if err := internal.OpenDB("file:" + path + "?cache=shared"); err != nil {
+		t.Fatal(err)
+	}
+	defer internal.CloseDB()
+
+	blog, err := internal.GetBlogEntry(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(blog.Tags, []string{"dp"}) || len(blog.Comments) != 2 || blog.Comments[1].ParentCommentId != 10 || blog.Comments[1].Text != "reply" {
+		t.Errorf("Unexpected moved blog: %+v", blog)
+	}
+
+	problems, err := internal.GetProblemsByTag("greedy", 1800)
+	if err != nil || len(problems) != 1 || !reflect.DeepEqual(problems[0].Tags, []string{"dp", "greedy"}) {
+		t.Errorf("Unexpected moved problems: %+v %v", problems, err)
+	}
+}
+
+func TestDatabaseURL(t *testing.T) {
+	t.Setenv("DATABASE_URL", "")
+	if dsn := internal.DatabaseURL(); dsn != "./db.sqlite3" {
+		t.Errorf("Expected the database of existing installs, got %s", dsn)
+	}
+
+	t.Setenv("DATABASE_URL", "postgres://localhost/analyzer")
+	if dsn := internal.DatabaseURL(); dsn != "postgres://localhost/analyzer" {
+		t.Errorf("Expected DATABASE_URL, got %s", dsn)
+	}
+}
+
+func TestOpenSQLiteURIWithParameters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.sqlite3")
+	if err := internal.OpenDB("file:" + path + "?cache=shared"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { internal.CloseDB() })
+
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the database at %s: %v", path, err)
+	}
+	if err := internal.SaveBlogEntry(&codeforces.BlogEntry{ID: 1, Title: "Round"}); err != nil {
+		t.Fatal(err)
+	}
+	if blog, err := internal.GetBlogEntry(1); err != nil || blog.Title != "Round" {
+		t.Errorf("Unexpected blog %+v %v", blog, err)
+	}
+}
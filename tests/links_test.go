@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"testing"
+
+	codeforces "github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+)
+
+var linkTests = []struct {
+	raw  string
+	want codeforces.Link
+}{
+	{"https://codeforces.com/contest/1923/problem/B", codeforces.Link{Kind: codeforces.LinkProblem, Type: "contest", ContestID: 1923, Index: "B", Path: "/contest/1923/problem/B"}},
+	{"http://www.codeforces.com/contest/1923/problem/b?locale=en#comment-1", codeforces.Link{Kind: codeforces.LinkProblem, Type: "contest", ContestID: 1923, Index: "B", Path: "/contest/1923/problem/B"}},
+	{"/contest/1923/problem/B1", codeforces.Link{Kind: codeforces.LinkProblem, Type: "contest", ContestID: 1923, Index: "B1", Path: "/contest/1923/problem/B1"}},
+	{"codeforces.com/problemset/problem/1923/A", codeforces.Link{Kind: codeforces.LinkProblem, Type: "problemset", ContestID: 1923, Index: "A", Path: "/problemset/problem/1923/A"}},
+	{"https://m1.codeforces.com/problemset/problem/1923/A/", codeforces.Link{Kind: codeforces.LinkProblem, Type: "problemset", ContestID: 1923, Index: "A", Path: "/problemset/problem/1923/A"}},
+	{"https://codeforces.ml/gym/104114/problem/C", codeforces.Link{Kind: codeforces.LinkProblem, Type: "gym", ContestID: 104114, Index: "C", Path: "/gym/104114/problem/C"}},
+	{"//codeforces.com/problemset/gymProblem/104114/C", codeforces.Link{Kind: codeforces.LinkProblem, Type: "gym", ContestID: 104114, Index: "C", Path: "/gym/104114/problem/C"}},
+	{"https://codeforces.com/problemsets/acmsguru/problem/99999/100", codeforces.Link{Kind: codeforces.LinkProblem, Type: "acmsguru", ContestID: 99999, Index: "100", Path: "/problemsets/acmsguru/problem/99999/100"}},
+	{"https://codeforces.com/edu/course/2/lesson/4/1/practice/contest/273169/problem/A", codeforces.Link{Kind: codeforces.LinkProblem, Type: "edu", ContestID: 273169, Index: "A", Path: "/edu/course/2/lesson/4/1/practice/contest/273169/problem/A"}},
+	{"https://codeforces.com/contest/1923", codeforces.Link{Kind: codeforces.LinkContest, Type: "contest", ContestID: 1923, Path: "/contest/1923"}},
+	{"https://codeforces.com/contest/1923/standings", codeforces.Link{Kind: codeforces.LinkContest, Type: "contest", ContestID: 1923, Path: "/contest/1923"}},
+	{"https://codeforces.com/gym/104114/submission/123456", codeforces.Link{Kind: codeforces.LinkSubmission, Type: "gym", ContestID: 104114, SubmissionID: 123456, Path: "/gym/104114/submission/123456"}},
+	{"https://codeforces.com/blog/entry/125137?locale=ru", codeforces.Link{Kind: codeforces.LinkBlog, BlogID: 125137, Path: "/blog/entry/125137"}},
+	{"codeforc.es/profile/tourist", codeforces.Link{Kind: codeforces.LinkProfile, Handle: "tourist", Path: "/profile/tourist"}},
+}
+
+func TestParseLink(t *testing.T) {
+	for _, test := range linkTests {
+		link, err := codeforces.ParseLink(test.raw)
+		if err != nil {
+			t.Errorf("ParseLink(%q): %v", test.raw, err)
+			continue
+		}
+
+		test.want.Raw = test.raw
+		if link != test.want {
+			t.Errorf("ParseLink(%q) = %+v, expected %+v", test.raw, link, test.want)
+		}
+	}
+
+	for _, raw := range []string{
+		"",
+		"https://example.com/contest/1923/problem/B",
+		"https://notcodeforces.com/contest/1923/problem/B",
+		"ftp://codeforces.com/contest/1923/problem/B",
+		"contest/1923/problem/B",
+		"https://codeforces.com/contest/abc/problem/B",
+		"https://codeforces.com/contest/0/problem/B",
+		"https://codeforces.com/contest/1923/problem/BB",
+		"https://codeforces.com/problemset/problem/1923",
+		"https://codeforces.com/blog/entry/",
+		"https://codeforces.com/profile/",
+		"https://codeforces.com/",
+	} {
+		if link, err := codeforces.ParseLink(raw); err != codeforces.ErrUnknownLink {
+			t.Errorf("ParseLink(%q) = %+v, %v, expected ErrUnknownLink", raw, link, err)
+		}
+	}
+}
+
+func TestFindLinks(t *testing.T) {
+	content := `<p>Solve <a href="/contest/1923/problem/B">B</a> and https://m2.codeforces.com/problemset/problem/1900/D. ` +
+		`Also see <a href="https://codeforces.com/blog/entry/42?locale=en">this</a>, notcodeforces.com/contest/1/problem/A ` +
+		`and <a href="/settings/general">settings</a> (by codeforces.com/profile/tourist).</p>`
+
+	links := codeforces.FindLinks(content)
+	expected := []string{"/contest/1923/problem/B", "/problemset/problem/1900/D", "/blog/entry/42", "/profile/tourist"}
+	if len(links) != len(expected) {
+		t.Fatalf("Expected %d links, got %+v", len(expected), links)
+	}
+	for i, link := range links {
+		if link.Path != expected[i] {
+			t.Errorf("Link %d: expected %s, got %s", i, expected[i], link.Path)
+		}
+	}
+	if links[1].Raw != "https://m2.codeforces.com/problemset/problem/1900/D" {
+		t.Errorf("Trailing punctuation should not be part of the link, got %q", links[1].Raw)
+	}
+}
+
+func FuzzParseLink(f *testing.F) {
+	for _, test := range linkTests {
+		f.Add(test.raw)
+	}
+	f.Add("https://codeforces.com/contest/1923/problem/B/extra")
+	f.Add("codeforces.com:8080/gym/1/submission/2")
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		link, err := codeforces.ParseLink(raw)
+		if err != nil {
+			if err != codeforces.ErrUnknownLink {
+				t.Fatalf("ParseLink(%q) returned unexpected error %v", raw, err)
+			}
+			return
+		}
+
+		// The canonical URL must parse back to the same link.
+		canonical, err := codeforces.ParseLink(link.URL())
+		if err != nil {
+			t.Fatalf("Canonical URL %q of %q doesn't parse: %v", link.URL(), raw, err)
+		}
+		canonical.Raw = link.Raw
+		if canonical != link {
+			t.Fatalf("ParseLink(%q) = %+v, but its canonical URL parses to %+v", raw, link, canonical)
+		}
+
+		for _, found := range codeforces.FindLinks(raw) {
+			if _, err := codeforces.ParseLink(found.Raw); err != nil {
+				t.Fatalf("FindLinks(%q) returned unparsable link %q", raw, found.Raw)
+			}
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal"
+)
+
+// baselineSchema is the schema InitDB created before migrations.
+var baselineSchema = []string{
+	"CREATE TABLE blog_entries (id INTEGER PRIMARY KEY, original_locale TEXT, creation_time INTEGER, author_handle TEXT, title TEXT, content TEXT, locale TEXT, modification_time INTEGER, allow_view_history BOOLEAN, tags JSON, rating INTEGER NULL, comments JSON)",
+	"CREATE TABLE problems (contest_id INTEGER NULL, problemset_name TEXT NULL, idx TEXT, name TEXT, type TEXT, points REAL, rating INTEGER NULL, tags JSON, solved_count INTEGER NULL, PRIMARY KEY (contest_id, idx))",
+	"CREATE TABLE referenced_problems (id INTEGER PRIMARY KEY, blog_id INTEGER, problem_type TEXT, problem_id INTEGER, idx TEXT, tags JSON)",
+	"CREATE INDEX idx_blog_entries_title ON blog_entries (title)",
+	"CREATE INDEX idx_blog_entries_tags ON blog_entries (tags)",
+	"CREATE INDEX idx_blog_entries_rating ON blog_entries (rating)",
+	"CREATE INDEX idx_problems_contest_id ON problems (contest_id)",
+	"CREATE INDEX idx_problems_idx ON problems (idx)",
+	"CREATE INDEX idx_problems_rating ON problems (rating)",
+	"CREATE INDEX idx_problems_tags ON problems (tags)",
+}
+
+func execAll(t *testing.T, path string, commands []string) {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for _, command := range commands {
+		if _, err := conn.Exec(command); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// execArgs runs query with args on the database at path. Seed rows with it
+// the way the baseline code saved them, binding JSON columns as []byte.
+func execArgs(t *testing.T, path string, query string, args ...any) {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Exec(query, args...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tableColumns returns the columns of every table of the database at path.
+func tableColumns(t *testing.T, path string) map[string][]string {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query("SELECT sqlite_master.name, columns.name FROM sqlite_master, pragma_table_info(sqlite_master.name) AS columns WHERE sqlite_master.type = 'table' AND sqlite_master.name NOT LIKE 'sqlite_%' ORDER BY sqlite_master.name, columns.cid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string][]string)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			t.Fatal(err)
+		}
+		columns[table] = append(columns[table], column)
+	}
+
+	return columns
+}
+
+func TestMigrateFromBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.sqlite3")
+	execAll(t, path, baselineSchema)
+	execArgs(t, path, "INSERT INTO blog_entries VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", 1, "en", 1, "author", "Problems", "<p>content</p>", "en", 7, true, []byte(`["dp","greedy"]`), 12,
+		[]byte(`[{"id":10,"commentatorHandle":"reader","text":"first","creationTimeSeconds":1,"rating":3},{"id":11,"commentatorHandle":"author","text":"reply","creationTimeSeconds":2,"parentCommentId":10}]`))
+	execArgs(t, path, "INSERT INTO blog_entries VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", 2, "ru", 3, "reader", "Empty", "", "ru", 3, false, []byte(`[]`), nil, []byte(`null`))
+	execArgs(t, path, "INSERT INTO problems VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", 1923, "", "B", "Monsters Attack!", "PROGRAMMING", 0, 1800, []byte(`["dp","greedy"]`), 100)
+	execArgs(t, path, "INSERT INTO problems VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", 1923, "", "C", "Find B", "PROGRAMMING", 0, 0, []byte(`[]`), 0)
+	execArgs(t, path, "INSERT INTO referenced_problems (blog_id, problem_type, problem_id, idx, tags) VALUES (?, ?, ?, ?, ?)", 1, "contest", 1923, "B", []byte(`["dp"]`))
+
+	if err := internal.OpenDB(path); err != nil {
+		t.Fatal(err)
+	}
+	defer internal.CloseDB()
+
+	migrations, err := internal.Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, err := internal.SchemaVersion(); err != nil || version != migrations[len(migrations)-1].Version {
+		t.Errorf("Expected the last migration to be applied, got version %d %v", version, err)
+	}
+
+	blog, err := internal.GetBlogEntry(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blog.Title != "Problems" || blog.Content != "<p>content</p>" || blog.Rating != 12 || blog.ModificationTimeSeconds != 7 || !reflect.DeepEqual(blog.Tags, []string{"dp", "greedy"}) {
+		t.Errorf("Unexpected migrated blog: %+v", blog)
+	}
+	if len(blog.Comments) != 2 || blog.Comments[0].Rating != 3 || blog.Comments[1].ParentCommentId != 10 || blog.Comments[1].CommentatorHandle != "author" {
+		t.Errorf("Unexpected migrated comments: %+v", blog.Comments)
+	}
+	if blog, err := internal.GetBlogEntry(2); err != nil || blog.Locale != "ru" || len(blog.Tags) != 0 || len(blog.Comments) != 0 {
+		t.Errorf("Unexpected migrated blog: %+v %v", blog, err)
+	}
+
+	problems, err := internal.GetContestProblems(1923)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 2 || problems[0].SolvedCount != 100 || !reflect.DeepEqual(problems[0].Tags, []string{"dp", "greedy"}) || len(problems[1].Tags) != 0 {
+		t.Errorf("Unexpected migrated problems: %+v", problems)
+	}
+
+	referenced, err := internal.GetReferencedProblems(1)
+	if err != nil || len(referenced) != 1 || !reflect.DeepEqual(referenced[0].Tags, []string{"dp"}) || referenced[0].Stale {
+		t.Errorf("Unexpected migrated referenced problems: %v %v", referenced, err)
+	}
+	internal.CloseDB()
+
+	// A migrated database ends up with the same tables as a new one.
+	newPath := filepath.Join(t.TempDir(), "db.sqlite3")
+	if err := internal.OpenDB(newPath); err != nil {
+		t.Fatal(err)
+	}
+	internal.CloseDB()
+	if migrated, created := tableColumns(t, path), tableColumns(t, newPath); !reflect.DeepEqual(migrated, created) {
+		t.Errorf("Migrated schema differs from a new one:\n%v\n%v", migrated, created)
+	}
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.sqlite3")
+	execAll(t, path, baselineSchema)
+
+	if err := internal.ConnectDB(path); err != nil {
+		t.Fatal(err)
+	}
+	defer internal.CloseDB()
+
+	migrations, err := internal.Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, migration := range migrations {
+		if i > 0 && migration.Version <= migrations[i-1].Version {
+			t.Errorf("Migrations are out of order: %v", migrations)
+		}
+	}
+
+	pending, err := internal.Migrate(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != len(migrations) {
+		t.Errorf("Expected every migration to be pending, got %v", pending)
+	}
+	if version, err := internal.SchemaVersion(); err != nil || version != 0 {
+		t.Errorf("Dry run changed the schema version to %d %v", version, err)
+	}
+	if _, ok := tableColumns(t, path)["comments"]; ok {
+		t.Error("Dry run created tables")
+	}
+
+	if applied, err := internal.Migrate(false); err != nil || len(applied) != len(migrations) {
+		t.Fatalf("Expected every migration to be applied, got %v %v", applied, err)
+	}
+	if pending, err := internal.Migrate(true); err != nil || len(pending) != 0 {
+		t.Errorf("Expected no pending migrations, got %v %v", pending, err)
+	}
+}
@@ -1,11 +1,15 @@
 package tests
 
 import (
+	"errors"
+	"io/fs"
+	"net/http"
 	"os"
 	"strings"
 	"testing"
 
 	codeforces "github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces/cftest"
 	"github.com/joho/godotenv"
 )
 
@@ -15,10 +19,28 @@ func TestMain(m *testing.M) {
 	if err := godotenv.Load("../.env"); err != nil {
 		envLoadError = err
 	}
+	codeforces.DefaultClient = newFixtureClient()
 
 	os.Exit(m.Run())
 }
 
+// newFixtureClient returns a client that serves responses from testdata/fixtures,
+// or records them there when CF_RECORD is set.
+func newFixtureClient() *codeforces.Client {
+	mode := cftest.ModeFromEnv()
+
+	client := codeforces.NewClientFromEnv()
+	client.HTTPClient = &http.Client{
+		Transport: cftest.NewTransport("testdata/fixtures", mode),
+		Timeout:   codeforces.DefaultTimeout,
+	}
+	if mode == cftest.ModeReplay {
+		client.Limiter = nil
+	}
+
+	return client
+}
+
 func TestGetBlogEntry(t *testing.T) {
 	blogEntry, err := codeforces.GetBlogEntry(62865)
 	if err != nil {
@@ -28,8 +50,11 @@ func TestGetBlogEntry(t *testing.T) {
 	if blogEntry.ID != 62865 {
 		t.Error("BlogEntry ID mismatch")
 	}
-	if len(blogEntry.Comments) < 123 {
-		t.Error("Invalid number of comments")
+	if !strings.Contains(blogEntry.Content, "/problemset/problem/1923/E") {
+		t.Error("BlogEntry content mismatch")
+	}
+	if len(blogEntry.Comments) != 24 {
+		t.Errorf("Invalid number of comments: expected 24, got %d", len(blogEntry.Comments))
 	}
 }
 
@@ -40,8 +65,8 @@ func TestGetHacks(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(hacks) < 2191 {
-		t.Error("Invalid number of hacks")
+	if len(hacks) != 40 {
+		t.Errorf("Invalid number of hacks: expected 40, got %d", len(hacks))
 	}
 }
 
@@ -51,8 +76,8 @@ func TestGetContestList(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(contests) == 0 {
-		t.Error("No contests found")
+	if len(contests) != 13 {
+		t.Errorf("Invalid number of contests: expected 13, got %d", len(contests))
 	}
 }
 
@@ -63,8 +88,8 @@ func TestGetRatingChanges(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(ratingChanges) != 16715 {
-		t.Error("Invalid number of rating changes")
+	if len(ratingChanges) != 60 {
+		t.Errorf("Invalid number of rating changes: expected 60, got %d", len(ratingChanges))
 	}
 }
 
@@ -79,7 +104,7 @@ func TestGetStandings(t *testing.T) {
 		t.Error("Contest ID mismatch")
 	}
 	if len(standings.Rows) != 10 {
-		t.Error("Invalid number of standings")
+		t.Errorf("Invalid number of standings: expected 10, got %d", len(standings.Rows))
 	}
 	for index, row := range standings.Rows {
 		if row.Rank > index+10 {
@@ -96,7 +121,7 @@ func TestGetStatus(t *testing.T) {
 	}
 
 	if len(status) != 10 {
-		t.Error("Invalid number of submissions")
+		t.Errorf("Invalid number of submissions: expected 10, got %d", len(status))
 	}
 }
 
@@ -106,11 +131,11 @@ func TestGetProblems(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(problems) < 9297 {
-		t.Error("Invalid number of problems")
+	if len(problems) != 180 {
+		t.Errorf("Invalid number of problems: expected 180, got %d", len(problems))
 	}
-	if len(problemStatistics) < 9297 {
-		t.Error("Invalid number of problem statistics")
+	if len(problemStatistics) != 180 {
+		t.Errorf("Invalid number of problem statistics: expected 180, got %d", len(problemStatistics))
 	}
 }
 
@@ -121,7 +146,7 @@ func TestGetRecentStatus(t *testing.T) {
 	}
 
 	if len(status) != 10 {
-		t.Error("Invalid number of submissions")
+		t.Errorf("Invalid number of submissions: expected 10, got %d", len(status))
 	}
 }
 
@@ -132,7 +157,7 @@ func TestGetRecentActions(t *testing.T) {
 	}
 
 	if len(actions) != 10 {
-		t.Error("Invalid number of recent actions")
+		t.Errorf("Invalid number of recent actions: expected 10, got %d", len(actions))
 	}
 }
 
@@ -143,8 +168,8 @@ func TestGetBlogEntries(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(blogEntries) < 418 {
-		t.Error("Invalid number of blog entries")
+	if len(blogEntries) != 30 {
+		t.Errorf("Invalid number of blog entries: expected 30, got %d", len(blogEntries))
 	}
 }
 
@@ -160,8 +185,12 @@ func TestGetFriends(t *testing.T) {
 
 	user := codeforces.User{Handle: os.Getenv("CF_HANDLE")}
 	friends, err := user.GetFriends(false)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skip("No user.friends fixture for CF_HANDLE, run make test-record to record it")
+		return
+	}
 	if err != nil {
-		if strings.Contains(err.Error(), "You have to be authenticated to use this method") {
+		if errors.Is(err, codeforces.ErrAuthenticationRequired) {
 			t.Skip("Authentication required")
 			return
 		}
@@ -192,8 +221,8 @@ func TestGetRatedList(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(users) < 1 {
-		t.Error("Invalid number of rated users")
+	if len(users) != 20 {
+		t.Errorf("Invalid number of rated users: expected 20, got %d", len(users))
 	}
 }
 
@@ -204,7 +233,24 @@ func TestGetRating(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(ratingChanges) < 75 {
-		t.Error("Invalid number of rating changes")
+	if len(ratingChanges) != 80 {
+		t.Errorf("Invalid number of rating changes: expected 80, got %d", len(ratingChanges))
+	}
+}
+
+func TestGetUserStatus(t *testing.T) {
+	user := codeforces.User{Handle: "ArshiaDadras"}
+	status, err := user.GetStatus(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(status) != 10 {
+		t.Errorf("Invalid number of submissions: expected 10, got %d", len(status))
+	}
+	for _, submission := range status {
+		if len(submission.Author.Members) == 0 || submission.Author.Members[0].Handle != user.Handle {
+			t.Error("Submission author mismatch")
+		}
 	}
 }
@@ -0,0 +1,427 @@
+package tests
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal"
+	codeforces "github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	testStore(t, func(t *testing.T) string {
+		return filepath.Join(t.TempDir(), "db.sqlite3")
+	})
+}
+
+// TestPostgresStore runs against the database at CF_TEST_POSTGRES_DSN, e.g.
+// the container started by make test-postgres. Its public schema is
+// dropped before each test.
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("CF_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("CF_TEST_POSTGRES_DSN is not set")
+	}
+
+	testStore(t, func(t *testing.T) string {
+		conn, err := sql.Open("postgres", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		if _, err := conn.Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public"); err != nil {
+			t.Fatal(err)
+		}
+		return dsn
+	})
+}
+
+// testStore is the conformance suite of Store implementations. newDSN
+// returns the DSN of an empty database.
+func testStore(t *testing.T, newDSN func(t *testing.T) string) {
+	open := func(t *testing.T) internal.Store {
+		t.Helper()
+
+		store, err := internal.OpenStore(newDSN(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { store.Close() })
+
+		if _, err := store.Migrate(false); err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}
+
+	t.Run("Migrate", func(t *testing.T) {
+		store := open(t)
+
+		migrations, err := store.Migrations()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version, err := store.SchemaVersion(); err != nil || version != migrations[len(migrations)-1].Version {
+			t.Errorf("Expected version %d, got %d %v", migrations[len(migrations)-1].Version, version, err)
+		}
+		for _, dryRun := range []bool{true, false} {
+			if pending, err := store.Migrate(dryRun); err != nil || len(pending) != 0 {
+				t.Errorf("Expected no pending migrations, got %v %v", pending, err)
+			}
+		}
+	})
+
+	t.Run("BlogEntries", func(t *testing.T) {
+		store := open(t)
+
+		if _, err := store.GetBlogEntry(1); err != sql.ErrNoRows {
+			t.Errorf("Expected sql.ErrNoRows for a missing blog, got %v", err)
+		}
+
+		blog := &codeforces.BlogEntry{
+			ID:                      1,
+			OriginalLocale:          "en",
+			CreationTimeSeconds:     1700000000,
+			AuthorHandle:            "author",
+			Title:                   "Problems",
+			Content:                 "<p>content</p>",
+			Locale:                  "en",
+			ModificationTimeSeconds: 1700000100,
+			AllowViewHistory:        true,
+			Tags:                    []string{"dp", "greedy"},
+			Rating:                  42,
+			Comments: []codeforces.Comment{
+				{ID: 10, CreationTimeSeconds: 1700000200, CommentatorHandle: "reader", Locale: "en", Text: "first", Rating: 3},
+				{ID: 11, CreationTimeSeconds: 1700000300, CommentatorHandle: "author", Locale: "en", Text: "reply", ParentCommentId: 10, Rating: -1},
+			},
+		}
+		if err := store.SaveBlogEntry(blog); err != nil {
+			t.Fatal(err)
+		}
+		if saved, err := store.GetBlogEntry(1); err != nil || !reflect.DeepEqual(saved, blog) {
+			t.Errorf("Saved blog differs:\n%+v\n%+v %v", saved, blog, err)
+		}
+
+		blog.Title, blog.Rating, blog.Tags = "Problems, updated", 50, []string{"greedy"}
+		blog.Comments[0].Text = "first, edited"
+		if err := store.SaveBlogEntry(blog); err != nil {
+			t.Fatal(err)
+		}
+		if saved, err := store.GetBlogEntry(1); err != nil || !reflect.DeepEqual(saved, blog) {
+			t.Errorf("Updated blog differs:\n%+v\n%+v %v", saved, blog, err)
+		}
+
+		if err := store.SaveBlogEntry(&codeforces.BlogEntry{ID: 2, Title: "Other", Tags: []string{"dp"}}); err != nil {
+			t.Fatal(err)
+		}
+		if blogIDs, err := store.GetBlogIDsByTags([]string{"dp", "greedy"}); err != nil || !reflect.DeepEqual(blogIDs, []int{1, 2}) {
+			t.Errorf("Expected blogs 1 and 2, got %v %v", blogIDs, err)
+		}
+		if blogIDs, err := store.GetBlogIDsByTags([]string{"dp"}); err != nil || !reflect.DeepEqual(blogIDs, []int{2}) {
+			t.Errorf("Expected blog 2 only, got %v %v", blogIDs, err)
+		}
+	})
+
+	t.Run("Problems", func(t *testing.T) {
+		store := open(t)
+
+		problems := []*codeforces.Problem{
+			{ContestID: 1923, Index: "C", Name: "Find B", Type: "PROGRAMMING", Points: 1250, Rating: 1800, Tags: []string{"math"}, SolvedCount: 10},
+			{ContestID: 1923, Index: "B", Name: "Monsters Attack!", Type: "PROGRAMMING", Points: 1000, Rating: 1800, Tags: []string{"dp", "greedy"}, SolvedCount: 20},
+			{ContestID: 1900, Index: "D", Name: "Small GCD", Type: "PROGRAMMING", Rating: 2000, Tags: []string{"dp"}},
+		}
+		for _, problem := range problems {
+			if err := store.SaveProblem(problem); err != nil {
+				t.Fatal(err)
+			}
+		}
+		problems[0].SolvedCount, problems[0].Tags = 11, []string{"binary search", "math"}
+		if err := store.SaveProblem(problems[0]); err != nil {
+			t.Fatal(err)
+		}
+
+		contest, err := store.GetContestProblems(1923)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(contest, []*codeforces.Problem{problems[1], problems[0]}) {
+			t.Errorf("Unexpected contest problems: %+v", contest)
+		}
+
+		if tagged, err := store.GetProblemsByTag("dp", 1800); err != nil || len(tagged) != 1 || tagged[0].Index != "B" {
+			t.Errorf("Expected 1923B, got %+v %v", tagged, err)
+		}
+		if tagged, err := store.GetProblemsByTag("dp", 0); err != nil || len(tagged) != 2 {
+			t.Errorf("Expected two problems tagged dp, got %+v %v", tagged, err)
+		}
+	})
+
+	t.Run("ReferencedProblems", func(t *testing.T) {
+		store := open(t)
+
+		referenced := &codeforces.ReferencedProblem{BlogID: 1, ProblemType: "contest", ProblemID: 1923, Index: "B", Tags: []string{"dp"}, Snippet: "1923B is dp"}
+		if err := store.SaveReferencedProblem(referenced); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SaveReferencedProblem(&codeforces.ReferencedProblem{BlogID: 1, ProblemType: "contest", ProblemID: 1923, Index: "B", Tags: []string{"greedy"}, Snippet: "1923B is greedy"}); err != nil {
+			t.Fatal(err)
+		}
+		comment := &codeforces.ReferencedProblem{BlogID: 1, ProblemType: "gym", ProblemID: 104114, Index: "C", Tags: []string{}, Snippet: "gym", CommentID: 10, CommentAuthor: "reader"}
+		if err := store.SaveReferencedProblem(comment); err != nil {
+			t.Fatal(err)
+		}
+
+		saved, err := store.GetReferencedProblems(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []*codeforces.ReferencedProblem{
+			{BlogID: 1, ProblemType: "contest", ProblemID: 1923, Index: "B", Tags: []string{"dp", "greedy"}, Snippet: "1923B is greedy"},
+			comment,
+		}
+		if !reflect.DeepEqual(saved, expected) {
+			t.Errorf("Unexpected referenced problems:\n%+v\n%+v", saved, expected)
+		}
+	})
+
+	t.Run("CommentsMentioningProblem", func(t *testing.T) {
+		store := open(t)
+
+		if err := store.SaveUser(&codeforces.User{Handle: "reader", Rating: 1500, MaxRating: 1600, Rank: "specialist"}); err != nil {
+			t.Fatal(err)
+		}
+		blog := &codeforces.BlogEntry{
+			ID:           1,
+			AuthorHandle: "author",
+			Comments: []codeforces.Comment{
+				{ID: 10, CommentatorHandle: "reader", CreationTimeSeconds: 1, Text: "1923B"},
+				{ID: 11, CommentatorHandle: "author", CreationTimeSeconds: 2, Text: "1923B too"},
+				{ID: 12, CommentatorHandle: "reader", CreationTimeSeconds: 3, Text: "1923C"},
+			},
+		}
+		if err := store.SaveBlogEntry(blog); err != nil {
+			t.Fatal(err)
+		}
+		for _, mention := range []*codeforces.ReferencedProblem{
+			{BlogID: 1, ProblemType: "problemset", ProblemID: 1923, Index: "B", CommentID: 10, CommentAuthor: "reader"},
+			{BlogID: 1, ProblemType: "contest", ProblemID: 1923, Index: "B", CommentID: 11, CommentAuthor: "author"},
+			{BlogID: 1, ProblemType: "contest", ProblemID: 1923, Index: "C", CommentID: 12, CommentAuthor: "reader"},
+		} {
+			if err := store.SaveReferencedProblem(mention); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		comments, err := store.GetCommentsMentioningProblem("reader", 1923, "B")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(comments, blog.Comments[:1]) {
+			t.Errorf("Expected reader's comment on 1923B, got %+v", comments)
+		}
+	})
+	t.Run("BlogClasses", func(t *testing.T) {
+		store := open(t)
+
+		for _, blogID := range []int{1, 2, 3} {
+			if err := store.SaveBlogEntry(&codeforces.BlogEntry{ID: blogID}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for blogID, class := range map[int]internal.BlogClass{1: internal.BlogEditorial, 2: internal.BlogEditorial, 3: internal.BlogQuestion} {
+			if err := store.SaveBlogClass(blogID, class, 1923); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := store.SaveBlogClass(2, internal.BlogEditorial, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		if class, contestID, err := store.GetBlogClass(1); err != nil || class != internal.BlogEditorial || contestID != 1923 {
+			t.Errorf("Expected an editorial of 1923, got %s %d %v", class, contestID, err)
+		}
+		if blogIDs, err := store.GetEditorialBlogIDs(1923); err != nil || !reflect.DeepEqual(blogIDs, []int{1}) {
+			t.Errorf("Expected blog 1 only, got %v %v", blogIDs, err)
+		}
+	})
+
+	t.Run("CommentHashes", func(t *testing.T) {
+		store := open(t)
+
+		blog := &codeforces.BlogEntry{ID: 1, Comments: []codeforces.Comment{{ID: 10}, {ID: 11}}}
+		if err := store.SaveBlogEntry(blog); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SaveCommentHash(1, &blog.Comments[0], "hash"); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SaveReferencedProblem(&codeforces.ReferencedProblem{BlogID: 1, ProblemType: "contest", ProblemID: 1923, Index: "B", Tags: []string{}, CommentID: 11}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.MarkCommentDeleted(1, 11, time.Unix(1700000000, 0)); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.MarkCommentReferencesStale(1, 11); err != nil {
+			t.Fatal(err)
+		}
+
+		states, err := store.GetCommentHashes(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := map[int]internal.CommentState{10: {Hash: "hash"}, 11: {Deleted: true}}; !reflect.DeepEqual(states, expected) {
+			t.Errorf("Expected %v, got %v", expected, states)
+		}
+		if commentIDs, err := store.GetDeletedCommentIDs(1); err != nil || !reflect.DeepEqual(commentIDs, []int{11}) {
+			t.Errorf("Expected comment 11 to be deleted, got %v %v", commentIDs, err)
+		}
+		if referenced, err := store.GetReferencedProblems(1); err != nil || len(referenced) != 1 || !referenced[0].Stale {
+			t.Errorf("Expected the mention in comment 11 to be stale, got %+v %v", referenced, err)
+		}
+	})
+
+	t.Run("ReferenceContexts", func(t *testing.T) {
+		store := open(t)
+
+		if err := store.SaveProblem(&codeforces.Problem{ContestID: 1923, Index: "B", Tags: []string{"dp", "greedy"}}); err != nil {
+			t.Fatal(err)
+		}
+		for _, referenced := range []*codeforces.ReferencedProblem{
+			{BlogID: 1, ProblemType: "problemset", ProblemID: 1923, Index: "B", Tags: []string{}, Snippet: "1923B"},
+			{BlogID: 1, ProblemType: "gym", ProblemID: 104114, Index: "C", Tags: []string{}, Snippet: "gym", CommentID: 10},
+		} {
+			if err := store.SaveReferencedProblem(referenced); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := store.MarkCommentReferencesStale(1, 10); err != nil {
+			t.Fatal(err)
+		}
+
+		references, err := store.GetReferenceContexts()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(references) != 1 || references[0].Problem != "contest/1923/B" || references[0].Text != "1923B" || !reflect.DeepEqual(references[0].OfficialTags, []string{"dp", "greedy"}) {
+			t.Fatalf("Unexpected reference contexts: %+v", references)
+		}
+
+		if err := store.SaveReferenceTags(references[0].ID, []string{"dp"}); err != nil {
+			t.Fatal(err)
+		}
+		if referenced, err := store.GetReferencedProblems(1); err != nil || !reflect.DeepEqual(referenced[0].Tags, []string{"dp"}) {
+			t.Errorf("Expected the mention to be tagged dp, got %+v %v", referenced, err)
+		}
+	})
+
+	t.Run("Frontier", func(t *testing.T) {
+		store := open(t)
+
+		if _, err := store.NextFrontierEntry(); err != sql.ErrNoRows {
+			t.Errorf("Expected sql.ErrNoRows for an empty frontier, got %v", err)
+		}
+		for _, entry := range []internal.FrontierEntry{{BlogID: 1, Depth: 2}, {BlogID: 2, Depth: 1, Priority: 10}} {
+			if err := store.EnqueueBlog(entry.BlogID, entry.Depth, entry.Priority, false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := store.EnqueueBlog(1, 1, 20, false); err != nil {
+			t.Fatal(err)
+		}
+
+		entry, err := store.NextFrontierEntry()
+		if err != nil || entry.BlogID != 1 || entry.Depth != 1 || entry.Priority != 20 {
+			t.Fatalf("Expected blog 1 at depth 1 with priority 20, got %+v %v", entry, err)
+		}
+		if err := store.FinishFrontierEntry(1, internal.FrontierFailed, errors.New("failed")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.NextFrontierEntry(); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.ResetInterruptedFrontier(time.Now().Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if entry, err := store.GetFrontierEntry(2); err != nil || entry.Status != internal.FrontierInProgress {
+			t.Errorf("Expected a fresh claim on blog 2 to be left alone, got %+v %v", entry, err)
+		}
+		if err := store.ResetInterruptedFrontier(time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		if entry, err := store.GetFrontierEntry(1); err != nil || entry.Status != internal.FrontierFailed || entry.Error != "failed" {
+			t.Errorf("Expected blog 1 to have failed, got %+v %v", entry, err)
+		}
+		if count, err := store.CountFrontier(internal.FrontierPending); err != nil || count != 1 {
+			t.Errorf("Expected blog 2 to be pending again, got %d %v", count, err)
+		}
+
+		if _, err := store.NextFrontierRetry(); err != sql.ErrNoRows {
+			t.Errorf("Expected sql.ErrNoRows with no retries, got %v", err)
+		}
+		if _, err := store.NextFrontierEntry(); err != nil {
+			t.Fatal(err)
+		}
+		retryAt := time.Now().Add(time.Hour).Truncate(time.Second)
+		if err := store.RetryFrontierEntry(2, retryAt, errors.New("call limit exceeded")); err != nil {
+			t.Fatal(err)
+		}
+		if entry, err := store.NextFrontierEntry(); err != sql.ErrNoRows {
+			t.Errorf("Expected blog 2 to wait for its retry, got %+v %v", entry, err)
+		}
+		if next, err := store.NextFrontierRetry(); err != nil || !next.Equal(retryAt) {
+			t.Errorf("Expected a retry at %s, got %s %v", retryAt, next, err)
+		}
+		if entry, err := store.GetFrontierEntry(2); err != nil || entry.Status != internal.FrontierPending || entry.Attempts != 1 {
+			t.Errorf("Expected blog 2 to be pending after 1 attempt, got %+v %v", entry, err)
+		}
+	})
+
+	t.Run("Daemon", func(t *testing.T) {
+		store := open(t)
+
+		if state, err := store.GetJobState("problems"); err != nil || state != nil {
+			t.Errorf("Expected no state for a job that never ran, got %+v %v", state, err)
+		}
+		now := time.Unix(1700000000, 0)
+		if err := store.SaveJobState("problems", now, errors.New("failed")); err != nil {
+			t.Fatal(err)
+		}
+		if state, err := store.GetJobState("problems"); err != nil || !state.LastRun.IsZero() || state.LastError != "failed" {
+			t.Errorf("Unexpected job state %+v %v", state, err)
+		}
+		if err := store.SaveJobState("problems", now, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SaveJobState("problems", now.Add(time.Hour), errors.New("failed")); err != nil {
+			t.Fatal(err)
+		}
+		if state, err := store.GetJobState("problems"); err != nil || !state.LastRun.Equal(now) || state.LastError != "failed" {
+			t.Errorf("Expected a failed run to keep the last successful run, got %+v %v", state, err)
+		}
+
+		for blogID, rating := range map[int]int{1: 200, 2: 50, 3: 150} {
+			if err := store.SaveBlogEntry(&codeforces.BlogEntry{ID: blogID, Rating: rating, ModificationTimeSeconds: blogID}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := store.SaveRecrawl(3, 3, time.Hour, now.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+
+		due, err := store.GetDueRecrawls(100, now)
+		if err != nil || len(due) != 1 || due[0].BlogID != 1 {
+			t.Errorf("Expected blog 1 to be due, got %+v %v", due, err)
+		}
+		due, err = store.GetDueRecrawls(100, now.Add(time.Hour))
+		if err != nil || len(due) != 2 || due[1].BlogID != 3 || due[1].Delay != time.Hour || due[1].LastModificationTime != 3 {
+			t.Errorf("Expected blogs 1 and 3 to be due, got %+v %v", due, err)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal"
+	"github.com/ArshiaDadras/Codeforces-Analyzer/internal/codeforces/cftest"
+)
+
+func TestInferTags(t *testing.T) {
+	url := "https://codeforces.com/contest/1923/problem/B"
+	content := `<p>Today's set.</p>
+<p><a href="https://codeforces.com/contest/1923/problem/B">1923B</a> is a nice <b>DP</b> problem, you can speed it up with a <i>segment tree</i>.</p>
+<p><a href="https://codeforces.com/contest/1900/problem/D">1900D</a> is about the convex hull.</p>`
+
+	scores := internal.InferTags(url, content)
+	confidence := make(map[string]float64)
+	for _, score := range scores {
+		confidence[score.Tag] = score.Confidence
+		if score.Confidence <= 0 || score.Confidence >= 1 {
+			t.Errorf("Confidence of %s out of range: %f", score.Tag, score.Confidence)
+		}
+	}
+	for i := 1; i < len(scores); i++ {
+		if scores[i-1].Confidence < scores[i].Confidence {
+			t.Errorf("Tags are not sorted by confidence: %v", scores)
+		}
+	}
+
+	for _, tag := range []string{"dp", "data structures"} {
+		if confidence[tag] < internal.TagConfidenceThreshold {
+			t.Errorf("Expected %s to be inferred confidently, got %f", tag, confidence[tag])
+		}
+	}
+	if confidence["geometry"] >= confidence["dp"] {
+		t.Errorf("A keyword near another problem should weigh less: geometry %f, dp %f", confidence["geometry"], confidence["dp"])
+	}
+
+	tags := internal.FindTagsForProblem(url, content)
+	for _, tag := range tags {
+		if confidence[tag] < internal.TagConfidenceThreshold {
+			t.Errorf("FindTagsForProblem returned %s below the threshold", tag)
+		}
+	}
+	if len(tags) == 0 {
+		t.Error("Expected FindTagsForProblem to return tags")
+	}
+
+	if tags := internal.FindTagsForProblem(url, `<p><a href="`+url+`">1923B</a> has a nice statement.</p>`); len(tags) != 0 {
+		t.Errorf("Expected no tags without keywords, got %v", tags)
+	}
+	if tags := internal.FindTagsForProblem(url, `<p><a href="`+url+`">1923B</a>: the editorial adds an update step.</p>`); len(tags) != 0 {
+		t.Errorf("Keywords must match whole words, got %v", tags)
+	}
+}
+
+func TestCrawlerInfersTags(t *testing.T) {
+	data := newCrawlerDataset()
+	data.BlogEntries[1].Content = `<p>Solve <a href="https://codeforces.com/problemset/problem/1900/D">1900D</a> greedily, then think about Dijkstra.</p>`
+	server := cftest.NewServer(data)
+	defer server.Close()
+
+	useClient(t, server.Client())
+	openTestDB(t)
+
+	if err := internal.CrawlBlogEntry(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+
+	referenced, err := internal.GetReferencedProblems(2)
+	if err != nil || len(referenced) != 1 {
+		t.Fatalf("Expected 1 referenced problem, got %v %v", referenced, err)
+	}
+	tags := make(map[string]bool)
+	for _, tag := range referenced[0].Tags {
+		tags[tag] = true
+	}
+	if !tags["greedy"] || !tags["shortest paths"] {
+		t.Errorf("Expected greedy and shortest paths to be inferred, got %v", referenced[0].Tags)
+	}
+}